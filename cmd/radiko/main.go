@@ -1,15 +1,26 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/moutend/go-radiko/internal/cli"
 )
 
 func main() {
-	cli.RootCommand.SetOutput(os.Stdout)
+	cli.RootCommand.SetOut(os.Stdout)
+	cli.RootCommand.SetErr(os.Stderr)
 
-	if err := cli.RootCommand.Execute(); err != nil {
-		os.Exit(-1)
+	// The commands are canceled by SIGINT or SIGTERM, then exit with cli.ExitCodeCanceled.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	err := cli.RootCommand.ExecuteContext(ctx)
+
+	stop()
+
+	if err != nil {
+		os.Exit(cli.ExitCode(err))
 	}
 }
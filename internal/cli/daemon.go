@@ -0,0 +1,354 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+	"github.com/moutend/go-radiko/pkg/radiko/scheduler"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var daemonCommand = &cobra.Command{
+	Use:   "daemon",
+	Short: "run scheduled recordings",
+	RunE:  daemonCommandRunE,
+}
+
+var daemonAddCommand = &cobra.Command{
+	Use:   "add <station ID>",
+	Short: "add a recording job",
+	Args:  cobra.ExactArgs(1),
+	RunE:  daemonAddCommandRunE,
+}
+
+var daemonListCommand = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "list recording jobs",
+	RunE:    daemonListCommandRunE,
+}
+
+var daemonRemoveCommand = &cobra.Command{
+	Use:     "remove",
+	Aliases: []string{"rm"},
+	Short:   "remove recording jobs",
+	RunE:    daemonRemoveCommandRunE,
+}
+
+var daemonRulesCommand = &cobra.Command{
+	Use:   "rules",
+	Short: "print programs matched by the recording rules",
+	RunE:  daemonRulesCommandRunE,
+}
+
+var daemonHistoryCommand = &cobra.Command{
+	Use:   "history",
+	Short: "print history of recordings",
+	RunE:  daemonHistoryCommandRunE,
+}
+
+func daemonCommandRunE(cmd *cobra.Command, args []string) error {
+	store, err := openStore(cmd)
+
+	if err != nil {
+		return err
+	}
+
+	username := viper.GetString("RADIKO_USERNAME")
+	password := viper.GetString("RADIKO_PASSWORD")
+
+	var cache radiko.SessionCache
+
+	if yes, _ := cmd.Flags().GetBool("no-session-cache"); !yes {
+		if cache, err = radiko.NewFileSessionCache(""); err != nil {
+			return err
+		}
+	}
+
+	rules, err := loadRules(cmd)
+
+	if err != nil {
+		return err
+	}
+
+	debug, _ := cmd.Flags().GetBool("debug")
+
+	s := scheduler.New(store, func(stationID string) *radiko.Client {
+		client := radiko.New(stationID, username, password)
+
+		if debug {
+			client.SetLogger(log.New(cmd.ErrOrStderr(), "debug: ", 0))
+		}
+		if cache != nil {
+			client.SetSessionCache(cache)
+		}
+
+		return client
+	})
+
+	s.Rules = rules
+
+	if debug {
+		s.SetLogger(log.New(cmd.ErrOrStderr(), "debug: ", 0))
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+
+	defer stop()
+
+	cmd.Printf("running %d jobs and %d rules\n", len(store.Jobs()), len(rules))
+
+	if err := s.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	return nil
+}
+
+func daemonAddCommandRunE(cmd *cobra.Command, args []string) error {
+	store, err := openStore(cmd)
+
+	if err != nil {
+		return err
+	}
+
+	job := scheduler.Job{
+		Kind:      scheduler.JobOnce,
+		StationID: strings.ToUpper(args[0]),
+	}
+
+	job.Name, _ = cmd.Flags().GetString("name")
+	job.Keyword, _ = cmd.Flags().GetString("keyword")
+	job.Duration, _ = cmd.Flags().GetDuration("length")
+	job.Live, _ = cmd.Flags().GetBool("live")
+	job.OutputDir, _ = cmd.Flags().GetString("output-dir")
+
+	// The daemon may run in another directory, so the relative path is resolved here.
+	if job.OutputDir != "" {
+		if job.OutputDir, err = filepath.Abs(job.OutputDir); err != nil {
+			return err
+		}
+	}
+
+	if atFlag, _ := cmd.Flags().GetString("at"); atFlag != "" {
+		if job.Start, err = time.ParseInLocation("2006-01-02 15:04", atFlag, radiko.JST); err != nil {
+			return fmt.Errorf("invalid time: %w", err)
+		}
+	}
+	if yes, _ := cmd.Flags().GetBool("weekly"); yes {
+		job.Kind = scheduler.JobWeekly
+	}
+	if job.Keyword != "" {
+		job.Kind = scheduler.JobKeyword
+	}
+
+	job, err = store.Add(job)
+
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("added job %s\n", job.ID)
+
+	return nil
+}
+
+func daemonListCommandRunE(cmd *cobra.Command, args []string) error {
+	store, err := openStore(cmd)
+
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ID\tKIND\tSTATION\tSTART\tLENGTH\tKEYWORD\tLIVE\tNAME")
+
+	for _, job := range store.Jobs() {
+		start := ""
+
+		if !job.Start.IsZero() {
+			start = job.Start.In(radiko.JST).Format("2006-01-02 15:04 Mon")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%v\t%s\n", job.ID, job.Kind, job.StationID, start, job.Duration, job.Keyword, job.Live, job.Name)
+	}
+
+	return w.Flush()
+}
+
+func daemonRemoveCommandRunE(cmd *cobra.Command, args []string) error {
+	store, err := openStore(cmd)
+
+	if err != nil {
+		return err
+	}
+	for _, id := range args {
+		if err := store.Remove(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func daemonRulesCommandRunE(cmd *cobra.Command, args []string) error {
+	rules, err := loadRules(cmd)
+
+	if err != nil {
+		return err
+	}
+
+	username := viper.GetString("RADIKO_USERNAME")
+	password := viper.GetString("RADIKO_PASSWORD")
+
+	var programs radiko.ProgramSlice
+
+	seen := map[string]bool{}
+
+	for _, rule := range rules {
+		for _, stationID := range rule.Stations {
+			stationID = strings.ToUpper(stationID)
+
+			if seen[stationID] {
+				continue
+			}
+
+			seen[stationID] = true
+
+			client := radiko.New(stationID, username, password)
+
+			if err := client.GetWeeklyPrograms(cmd.Context()); err != nil {
+				return err
+			}
+
+			programs = append(programs, client.Programs...)
+		}
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(w, "RULE\tSTATION\tSTART\tEND\tTITLE")
+
+	for _, job := range scheduler.RuleJobs(rules, programs) {
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\n",
+			strings.TrimPrefix(job.ID, "rule:"),
+			job.StationID,
+			job.Start.In(radiko.JST).Format("2006-01-02 15:04"),
+			job.Start.Add(job.Duration).In(radiko.JST).Format("15:04"),
+			job.Name,
+		)
+	}
+
+	return w.Flush()
+}
+
+func daemonHistoryCommandRunE(cmd *cobra.Command, args []string) error {
+	store, err := openStore(cmd)
+
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(w, "JOB\tSTATION\tSTART\tFINISHED\tOUTPUT\tERROR")
+
+	for _, run := range store.History() {
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			run.Window.JobID,
+			run.Window.StationID,
+			run.Window.Start.In(radiko.JST).Format("2006-01-02 15:04"),
+			run.FinishedAt.In(radiko.JST).Format("2006-01-02 15:04"),
+			run.Window.Output,
+			run.Error,
+		)
+	}
+
+	return w.Flush()
+}
+
+func openStore(cmd *cobra.Command) (*scheduler.Store, error) {
+	path, _ := cmd.Flags().GetString("store")
+
+	if path == "" {
+		configDir, err := os.UserConfigDir()
+
+		if err != nil {
+			return nil, err
+		}
+
+		path = filepath.Join(configDir, "go-radiko", "jobs.json")
+	}
+
+	return scheduler.Open(path)
+}
+
+// loadRules loads the recording rules from the file specified by --rules, or from the 'rules' key of the configuration file.
+func loadRules(cmd *cobra.Command) ([]scheduler.Rule, error) {
+	v := viper.GetViper()
+
+	if path, _ := cmd.Flags().GetString("rules"); path != "" {
+		v = viper.New()
+		v.SetConfigFile(path)
+
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read rules: %w", err)
+		}
+	}
+
+	var rules []scheduler.Rule
+
+	if err := v.UnmarshalKey("rules", &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+
+	names := map[string]bool{}
+
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name: %q", rule.Name)
+		}
+
+		names[rule.Name] = true
+	}
+
+	return rules, nil
+}
+
+func init() {
+	RootCommand.AddCommand(daemonCommand)
+
+	daemonCommand.AddCommand(daemonAddCommand)
+	daemonCommand.AddCommand(daemonListCommand)
+	daemonCommand.AddCommand(daemonRemoveCommand)
+	daemonCommand.AddCommand(daemonRulesCommand)
+	daemonCommand.AddCommand(daemonHistoryCommand)
+
+	daemonCommand.PersistentFlags().String("store", "", "path to the job store (default is 'go-radiko/jobs.json' under the user config directory)")
+	daemonCommand.PersistentFlags().String("rules", "", "path to the rules file (default is the 'rules' key of the configuration file)")
+
+	daemonAddCommand.Flags().String("at", "", "start time with 'YYYY-MM-DD hh:mm' layout in JST (e.g. '2026-10-17 21:00')")
+	daemonAddCommand.Flags().DurationP("length", "l", 0, "recording length (e.g. '30m')")
+	daemonAddCommand.Flags().Bool("weekly", false, "record every week at the weekday and the time of '--at'")
+	daemonAddCommand.Flags().StringP("keyword", "k", "", "record every program whose title or performers contain the keyword")
+	daemonAddCommand.Flags().Bool("live", false, "record the live stream on air instead of the timefree stream after the broadcast")
+	daemonAddCommand.Flags().StringP("name", "n", "", "name of the job used in the output file name")
+	daemonAddCommand.Flags().String("output-dir", "", "directory of the recorded files (default is the current directory)")
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+)
+
+const (
+	ExitCodeOK = iota
+	ExitCodeError
+	ExitCodeGeoRestricted
+	ExitCodeLoginFailed
+	ExitCodeAuthFailed
+	ExitCodeStationNotInArea
+	ExitCodeProgramNotFound
+	ExitCodeHTTPStatus
+	ExitCodeStationNotFound
+)
+
+// ExitCodeCanceled is the conventional exit code of a process interrupted by SIGINT.
+const ExitCodeCanceled = 130
+
+// ExitCode returns the exit code of the command which returned err.
+func ExitCode(err error) int {
+	var statusErr *radiko.HTTPStatusError
+
+	switch {
+	case err == nil:
+		return ExitCodeOK
+	case errors.Is(err, context.Canceled):
+		return ExitCodeCanceled
+	case errors.Is(err, radiko.ErrGeoRestricted):
+		return ExitCodeGeoRestricted
+	case errors.Is(err, radiko.ErrLoginFailed):
+		return ExitCodeLoginFailed
+	case errors.Is(err, radiko.ErrAuthFailed):
+		return ExitCodeAuthFailed
+	case errors.Is(err, radiko.ErrStationNotInArea):
+		return ExitCodeStationNotInArea
+	case errors.Is(err, radiko.ErrStationNotFound):
+		return ExitCodeStationNotFound
+	case errors.Is(err, radiko.ErrProgramNotFound):
+		return ExitCodeProgramNotFound
+	case errors.As(err, &statusErr):
+		return ExitCodeHTTPStatus
+	default:
+		return ExitCodeError
+	}
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+	"github.com/moutend/go-radiko/pkg/radiko/podcast"
+	"github.com/spf13/cobra"
+)
+
+var feedCommand = &cobra.Command{
+	Use:   "feed",
+	Short: "generate podcast feeds of recordings",
+	RunE:  feedCommandRunE,
+}
+
+func feedCommandRunE(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return nil
+	}
+
+	dir := args[0]
+
+	baseURL, _ := cmd.Flags().GetString("base-url")
+
+	if baseURL == "" {
+		return fmt.Errorf("--base-url is required")
+	}
+
+	by, _ := cmd.Flags().GetString("by")
+
+	if by != string(podcast.GroupByStation) && by != string(podcast.GroupByProgram) {
+		return fmt.Errorf("invalid --by: %q", by)
+	}
+
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+
+	if outputDir == "" {
+		outputDir = dir
+	}
+
+	episodes, err := podcast.Scan(dir)
+
+	if err != nil {
+		return err
+	}
+
+	groups := podcast.Group(episodes, podcast.GroupBy(by))
+	keys := make([]string, 0, len(groups))
+
+	for key := range groups {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		group := groups[key]
+		program := group[0].Program
+
+		// The sidecar without the station can't be named after it.
+		if program.StationID == "" {
+			cmd.PrintErrf("skipped %d episodes without station ID\n", len(group))
+
+			continue
+		}
+		title := program.StationName
+
+		if title == "" {
+			title = program.StationID
+		}
+		if podcast.GroupBy(by) == podcast.GroupByProgram {
+			title = fmt.Sprintf("%s (%s)", program.Title, title)
+		}
+
+		feed := podcast.Feed{
+			Title:   title,
+			BaseURL: baseURL,
+		}
+
+		path := filepath.Join(outputDir, feedFileName(key))
+
+		if err := writeFeed(path, feed, group); err != nil {
+			return err
+		}
+
+		cmd.Printf("%s: %d episodes\n", path, len(group))
+	}
+
+	return nil
+}
+
+// feedFileName returns the file name of the feed such as 'FMT.xml' or 'FMT_Tokyo Breakfast.xml'.
+func feedFileName(key string) string {
+	return radiko.SanitizeFileName(key) + ".xml"
+}
+
+func writeFeed(path string, feed podcast.Feed, episodes []podcast.Episode) error {
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if err := feed.Write(file, episodes); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+func init() {
+	RootCommand.AddCommand(feedCommand)
+
+	feedCommand.Flags().String("base-url", "", "URL where the directory of recordings is served (e.g. 'https://example.com/radio')")
+	feedCommand.Flags().String("by", "station", "make a feed per 'station' or per 'program'")
+	feedCommand.Flags().String("output-dir", "", "directory of the feeds (default is the directory of recordings)")
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var guideCommand = &cobra.Command{
+	Use:     "guide",
+	Aliases: []string{"g"},
+	Short:   "print program guide of a station",
+	RunE:    guideCommandRunE,
+}
+
+func guideCommandRunE(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return nil
+	}
+
+	ctx := cmd.Context()
+
+	stationID := strings.ToUpper(args[0])
+	username := viper.GetString("RADIKO_USERNAME")
+	password := viper.GetString("RADIKO_PASSWORD")
+
+	client := radiko.New(stationID, username, password)
+
+	if yes, _ := cmd.Flags().GetBool("debug"); yes {
+		client.SetLogger(log.New(cmd.ErrOrStderr(), "debug: ", 0))
+	}
+
+	dateFlag, _ := cmd.Flags().GetString("date")
+	week, _ := cmd.Flags().GetBool("week")
+
+	date, err := radiko.ParseBroadcastDate(dateFlag)
+
+	if err != nil {
+		return fmt.Errorf("invalid date: %w", err)
+	}
+	if err := client.GetGuide(ctx, date, week); err != nil {
+		return err
+	}
+	if yes, _ := cmd.Flags().GetBool("json"); yes {
+		data, err := json.MarshalIndent(client.Programs, "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("%s\n", data)
+
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(w, "DATE\tSTART\tEND\tTITLE\tPERFORMERS")
+
+	for _, program := range client.Programs {
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\n",
+			program.Start.Format("2006-01-02"),
+			program.Start.Format("15:04"),
+			program.End.Format("15:04"),
+			program.Title,
+			strings.Join(program.Performers, ", "),
+		)
+	}
+
+	return w.Flush()
+}
+
+func init() {
+	RootCommand.AddCommand(guideCommand)
+
+	guideCommand.PersistentFlags().String("date", "", "date of the program guide with 'YYYY-MM-DD' layout (default is today)")
+	guideCommand.PersistentFlags().BoolP("week", "w", false, "print program guide for the coming week, from today or '--date'")
+	guideCommand.PersistentFlags().BoolP("json", "j", false, "print program guide as JSON")
+}
@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"fmt"
 	"log"
+	"os"
 	"strings"
 
 	"github.com/moutend/go-radiko/pkg/radiko"
@@ -27,26 +29,87 @@ func playCommandRunE(cmd *cobra.Command, args []string) error {
 		playbackVolume = 100
 	}
 
+	sink, err := audioSink(cmd, playbackVolume)
+
+	if err != nil {
+		return err
+	}
+
 	ctx := cmd.Context()
 
-	stationID := strings.ToUpper(args[0])
 	username := viper.GetString("RADIKO_USERNAME")
 	password := viper.GetString("RADIKO_PASSWORD")
 
-	client := radiko.New(stationID, username, password)
+	policy := radiko.DefaultReconnectPolicy
+	policy.MaxAttempts, _ = cmd.Flags().GetInt("reconnect")
+
+	client := radiko.New("", username, password, radiko.WithReconnectPolicy(policy))
 
 	if yes, _ := cmd.Flags().GetBool("debug"); yes {
 		client.SetLogger(log.New(cmd.ErrOrStderr(), "debug: ", 0))
 	}
-	if err := client.Play(ctx, playbackVolume); err != nil {
+	if yes, _ := cmd.Flags().GetBool("no-session-cache"); !yes {
+		cache, err := radiko.NewFileSessionCache("")
+
+		if err != nil {
+			return err
+		}
+
+		client.SetSessionCache(cache)
+	}
+
+	stationID, err := resolveStation(cmd, client, strings.Join(args, " "))
+
+	if err != nil {
+		return err
+	}
+
+	client.SetStation(stationID)
+
+	if err := checkStation(cmd, client, stationID, false); err != nil {
+		return err
+	}
+	if err := client.PlayTo(ctx, sink); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// audioSink returns the sink selected by --output-backend.
+func audioSink(cmd *cobra.Command, playbackVolume int) (radiko.AudioSink, error) {
+	backend, _ := cmd.Flags().GetString("output-backend")
+	output, _ := cmd.Flags().GetString("output")
+
+	switch backend {
+	case "ffplay":
+		return radiko.FFplaySink(playbackVolume), nil
+	case "mpv":
+		return radiko.MPVSink(playbackVolume), nil
+	case "stdout":
+		// The audio is written to the standard output directly, so that the messages of cobra never get mixed into it.
+		return radiko.WriterSink(os.Stdout), nil
+	case "pcm":
+		return radiko.PCMSink(os.Stdout), nil
+	case "file", "fifo":
+		if output == "" {
+			return nil, fmt.Errorf("--output is required by %q backend", backend)
+		}
+		if backend == "file" {
+			return radiko.FileSink(output), nil
+		}
+
+		return radiko.NamedPipeSink(output), nil
+	default:
+		return nil, fmt.Errorf("unknown output backend %q, choose from ffplay, mpv, stdout, pcm, file and fifo", backend)
+	}
+}
+
 func init() {
 	RootCommand.AddCommand(playCommand)
 
 	playCommand.PersistentFlags().IntP("volume", "v", 100, "playback volume (min = 0, max = 100)")
+	playCommand.PersistentFlags().String("output-backend", "ffplay", "where the audio goes: 'ffplay', 'mpv', 'stdout' for ADTS, 'pcm' for 16-bit stereo PCM at 48kHz to stdout, 'file' or 'fifo' for ADTS to '--output'")
+	playCommand.PersistentFlags().StringP("output", "o", "", "path to the file or the named pipe of 'file' and 'fifo' backends")
+	playCommand.PersistentFlags().Int("reconnect", radiko.DefaultReconnectPolicy.MaxAttempts, "number of consecutive reconnections when the stream is interrupted (0 disables reconnection)")
 }
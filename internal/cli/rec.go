@@ -23,7 +23,66 @@ func recCommandRunE(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	ctx := cmd.Context()
+
+	stationID := strings.ToUpper(args[0])
+	username := viper.GetString("RADIKO_USERNAME")
+	password := viper.GetString("RADIKO_PASSWORD")
+
+	client := radiko.New(stationID, username, password)
+
+	if yes, _ := cmd.Flags().GetBool("debug"); yes {
+		client.SetLogger(log.New(cmd.ErrOrStderr(), "debug: ", 0))
+	}
+	if yes, _ := cmd.Flags().GetBool("no-session-cache"); !yes {
+		cache, err := radiko.NewFileSessionCache("")
+
+		if err != nil {
+			return err
+		}
+
+		client.SetSessionCache(cache)
+	}
+	if err := checkStation(cmd, client, stationID, true); err != nil {
+		return err
+	}
+
 	outputFile, _ := cmd.Flags().GetString("output")
+	programFlag, _ := cmd.Flags().GetString("program")
+	atFlag, _ := cmd.Flags().GetString("at")
+	parallel, _ := cmd.Flags().GetInt("parallel")
+
+	options := []radiko.RecOption{radiko.WithConcurrency(parallel)}
+
+	if yes, _ := cmd.Flags().GetBool("resume"); yes {
+		options = append(options, radiko.WithResume())
+	}
+
+	noTags, _ := cmd.Flags().GetBool("no-tags")
+	noSidecar, _ := cmd.Flags().GetBool("no-sidecar")
+
+	if noTags {
+		options = append(options, radiko.WithoutTags())
+	}
+	if programFlag != "" || atFlag != "" {
+		program, err := findProgram(cmd, client, programFlag, atFlag)
+
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("output") {
+			outputFile = program.FileName(".m4a")
+		}
+
+		if !noSidecar {
+			options = append(options, radiko.WithSidecar(program))
+		}
+
+		cmd.Printf("recording %s %s-%s %s to %s\n", program.StationID, program.Start.Format("2006-01-02 15:04"), program.End.Format("15:04"), program.Title, outputFile)
+
+		return client.RecProgram(ctx, program, outputFile, options...)
+	}
+
 	length, _ := cmd.Flags().GetDuration("length")
 
 	if length <= 0 {
@@ -42,28 +101,79 @@ func recCommandRunE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid date: %w", err)
 	}
 
+	// The tags and the sidecar are taken from the program on air at the target date if any.
+	if !noTags || !noSidecar {
+		if err := client.GetPrograms(ctx, radiko.BroadcastDate(date)); err == nil {
+			if program, ok := client.Programs.At(date); ok {
+				if !noTags {
+					options = append(options, radiko.WithTags(client.ProgramTags(ctx, program)))
+				}
+				if !noSidecar {
+					options = append(options, radiko.WithSidecar(program))
+				}
+			}
+		}
+	}
+	if err := client.Rec(ctx, date, length, outputFile, options...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// findProgram resolves a program from the program guide by its title or ID, or by the time when it is on air.
+func findProgram(cmd *cobra.Command, client *radiko.Client, query, at string) (radiko.Program, error) {
 	ctx := cmd.Context()
 
-	stationID := strings.ToUpper(args[0])
-	username := viper.GetString("RADIKO_USERNAME")
-	password := viper.GetString("RADIKO_PASSWORD")
+	if at != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04", at, radiko.JST)
 
-	client := radiko.New(stationID, username, password)
+		if err != nil {
+			return radiko.Program{}, fmt.Errorf("invalid time: %w", err)
+		}
 
-	if yes, _ := cmd.Flags().GetBool("debug"); yes {
-		client.SetLogger(log.New(cmd.ErrOrStderr(), "debug: ", 0))
+		if err := client.GetPrograms(ctx, radiko.BroadcastDate(t)); err != nil {
+			return radiko.Program{}, err
+		}
+
+		program, ok := client.Programs.At(t)
+
+		if !ok || (query != "" && len(radiko.ProgramSlice{program}.Search(query)) == 0) {
+			return radiko.Program{}, fmt.Errorf("%w: no program on air at %s", radiko.ErrProgramNotFound, at)
+		}
+
+		return program, nil
 	}
-	if err := client.Rec(ctx, date, length, outputFile); err != nil {
-		return err
+	if err := client.GetWeeklyPrograms(ctx); err != nil {
+		return radiko.Program{}, err
 	}
 
-	return nil
+	now := time.Now()
+	programs := client.Programs.Search(query)
+
+	// Pick the latest broadcast available on timefree.
+	for i := len(programs) - 1; i >= 0; i-- {
+		if !programs[i].End.After(now) {
+			return programs[i], nil
+		}
+	}
+	if len(programs) > 0 {
+		return radiko.Program{}, fmt.Errorf("%q is not available on timefree until %s", programs[0].Title, programs[0].End.Format("2006-01-02 15:04"))
+	}
+
+	return radiko.Program{}, fmt.Errorf("%w: %q", radiko.ErrProgramNotFound, query)
 }
 
 func init() {
 	RootCommand.AddCommand(recCommand)
 
-	recCommand.PersistentFlags().StringP("target", "t", "", "target date with 'YYYYMMDDhhmm` layout (e.g. '201901021234')")
-	recCommand.PersistentFlags().StringP("output", "o", "output.m4a", "output file name (default is 'output.m4a')")
+	recCommand.PersistentFlags().StringP("target", "t", "", "target date with RFC3339 layout (e.g. '2019-01-02T12:34:00+09:00')")
+	recCommand.PersistentFlags().StringP("output", "o", "output.m4a", "output file name, the '.aac' extension keeps raw ADTS audio (default is 'output.m4a' or derived from the program)")
 	recCommand.PersistentFlags().DurationP("length", "l", 0, "recording length (e.g. '10s' is 10 seconds / '10m' is 10 minutes) ")
+	recCommand.PersistentFlags().StringP("program", "p", "", "title or ID of the program to record instead of '--target' and '--length'")
+	recCommand.PersistentFlags().IntP("parallel", "P", 4, "number of segments downloaded at the same time")
+	recCommand.PersistentFlags().Bool("resume", false, "keep the downloaded audio in '<output>.part' and continue from it when the same recording is run again")
+	recCommand.PersistentFlags().Bool("no-tags", false, "don't embed the program title, performers, station, date, description and artwork into the output file")
+	recCommand.PersistentFlags().Bool("no-sidecar", false, "don't save the program as a JSON file next to the output file, which is used by 'radiko feed'")
+	recCommand.PersistentFlags().String("at", "", "record the program on air at the time with 'YYYY-MM-DD hh:mm' layout in JST (e.g. '2026-10-17 21:00')")
 }
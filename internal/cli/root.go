@@ -28,4 +28,5 @@ func rootPersistentPreRunE(cmd *cobra.Command, args []string) error {
 func init() {
 	RootCommand.PersistentFlags().BoolP("debug", "d", false, "enable debug output")
 	RootCommand.PersistentFlags().StringP("config", "c", "", "path to configuration file")
+	RootCommand.PersistentFlags().Bool("no-session-cache", false, "disable reusing auth token across invocations")
 }
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+	"github.com/moutend/go-radiko/pkg/radiko/relay"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var serveCommand = &cobra.Command{
+	Use:   "serve",
+	Short: "relay live streams over HTTP",
+	RunE:  serveCommandRunE,
+}
+
+func serveCommandRunE(cmd *cobra.Command, args []string) error {
+	username := viper.GetString("RADIKO_USERNAME")
+	password := viper.GetString("RADIKO_PASSWORD")
+	debug, _ := cmd.Flags().GetBool("debug")
+
+	var cache radiko.SessionCache
+
+	if yes, _ := cmd.Flags().GetBool("no-session-cache"); !yes {
+		c, err := radiko.NewFileSessionCache("")
+
+		if err != nil {
+			return err
+		}
+
+		cache = c
+	}
+
+	r := relay.New(func(stationID string) *radiko.Client {
+		client := radiko.New(stationID, username, password)
+
+		if debug {
+			client.SetLogger(log.New(cmd.ErrOrStderr(), "debug: ", 0))
+		}
+		if cache != nil {
+			client.SetSessionCache(cache)
+		}
+
+		return client
+	})
+
+	defer r.Close()
+
+	if debug {
+		r.SetLogger(log.New(cmd.ErrOrStderr(), "debug: ", 0))
+	}
+
+	addr, _ := cmd.Flags().GetString("addr")
+	server := &http.Server{Addr: addr, Handler: r}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+
+		// Stop the streams first, otherwise Shutdown waits for the listeners forever.
+		r.Close()
+		server.Shutdown(context.Background())
+	}()
+
+	cmd.Printf("listening on %s\n", addr)
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	RootCommand.AddCommand(serveCommand)
+
+	serveCommand.Flags().String("addr", ":8080", "address to listen on")
+}
@@ -2,7 +2,12 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/moutend/go-radiko/pkg/radiko"
 	"github.com/spf13/cobra"
@@ -17,6 +22,14 @@ var stationCommand = &cobra.Command{
 }
 
 func stationCommandRunE(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	table, _ := cmd.Flags().GetBool("table")
+	wide, _ := cmd.Flags().GetBool("wide")
+
+	if asJSON && (table || wide) {
+		return fmt.Errorf("--json can't be used with --table or --wide")
+	}
+
 	username := viper.GetString("RADIKO_USERNAME")
 	password := viper.GetString("RADIKO_PASSWORD")
 
@@ -29,17 +42,145 @@ func stationCommandRunE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(client.AllStations, "", "  ")
+	stations := radiko.StationSlice(client.AllStations)
 
-	if err != nil {
+	if regionID, _ := cmd.Flags().GetString("region"); regionID != "" {
+		if stations = stations.InRegion(regionID); len(stations) == 0 {
+			var ids []string
+
+			for _, region := range client.Regions {
+				ids = append(ids, region.ID)
+			}
+
+			return fmt.Errorf("unknown region %q, choose from %s", regionID, strings.Join(ids, ", "))
+		}
+	}
+	if areaFlag, _ := cmd.Flags().GetString("area"); areaFlag != "" {
+		areaID := radiko.NormalizeAreaID(areaFlag)
+
+		if _, ok := radiko.Prefectures[areaID]; !ok {
+			return fmt.Errorf("unknown area %q, choose from JP1 to JP47", areaFlag)
+		}
+
+		stations = stations.InArea(areaID)
+	}
+
+	// The stations are printed as JSON unless the table is requested.
+	if !table && !wide {
+		data, err := json.MarshalIndent(stations, "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("%s\n", data)
+
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+
+	if wide {
+		fmt.Fprintln(w, "ID\tNAME\tREGION\tAREA\tAREAFREE\tTIMEFREE\tTF DELAY\tWEBSITE\tLOGO\tBANNER")
+	} else {
+		fmt.Fprintln(w, "ID\tNAME\tREGION\tAREA\tAREAFREE\tTIMEFREE")
+	}
+	for _, station := range stations {
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s %s\t%v\t%v",
+			station.ID,
+			station.Name,
+			station.RegionID,
+			station.AreaID,
+			radiko.Prefectures[station.AreaID],
+			station.Areafree == 1,
+			station.Timefree == 1,
+		)
+
+		if wide {
+			logo, _ := station.Logo(224, 100)
+
+			fmt.Fprintf(w, "\t%s\t%s\t%s\t%s", time.Duration(station.TimefreeMaxDelay)*time.Second, station.Href, logo.URL, station.Banner)
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	return w.Flush()
+}
+
+// resolveStation returns the ID of the station whose ID, name or reading matches the query. The station in your area is preferred when several stations match.
+func resolveStation(cmd *cobra.Command, client *radiko.Client, query string) (string, error) {
+	if err := client.GetAllStations(cmd.Context()); err != nil {
+		return "", err
+	}
+
+	stations := radiko.StationSlice(client.AllStations)
+
+	if station, ok := stations.Find(query); ok {
+		return station.ID, nil
+	}
+
+	found := stations.Search(query)
+
+	if len(found) == 0 {
+		return "", fmt.Errorf("radiko: %q: %w", query, radiko.ErrStationNotFound)
+	}
+	if len(found) > 1 {
+		if err := client.GetAreaStations(cmd.Context()); err != nil {
+			return "", err
+		}
+		for _, station := range found {
+			if _, ok := radiko.StationSlice(client.AreaStations).Find(station.ID); ok {
+				found[0] = station
+
+				break
+			}
+		}
+	}
+
+	cmd.PrintErrf("%q is %s (%s)\n", query, found[0].ID, found[0].Name)
+
+	return found[0].ID, nil
+}
+
+// checkStation fails fast with the playable stations in the area when the station is not playable live, or on timefree if timefree is true.
+func checkStation(cmd *cobra.Command, client *radiko.Client, stationID string, timefree bool) error {
+	a, err := client.CheckStation(cmd.Context(), stationID)
+
+	if err != nil && !errors.Is(err, radiko.ErrStationNotFound) {
 		return err
 	}
+	if err == nil && (a.Live && !timefree || a.Timefree && timefree) {
+		return nil
+	}
+
+	var playable []string
+
+	for _, station := range client.AreaStations {
+		if !timefree || station.Timefree == 1 {
+			playable = append(playable, station.ID)
+		}
+	}
+
+	suggestion := fmt.Sprintf("stations available in %s: %s", client.AreaName, strings.Join(playable, ", "))
 
-	cmd.Printf("%s\n", data)
+	if err != nil {
+		return fmt.Errorf("%w; %s", err, suggestion)
+	}
+	if timefree {
+		return fmt.Errorf("radiko: %s: %w on timefree; %s", stationID, radiko.ErrStationNotInArea, suggestion)
+	}
 
-	return nil
+	return fmt.Errorf("radiko: %s: %w; %s", stationID, radiko.ErrStationNotInArea, suggestion)
 }
 
 func init() {
 	RootCommand.AddCommand(stationCommand)
+
+	stationCommand.Flags().String("region", "", "list only the stations of the region (e.g. 'kanto')")
+	stationCommand.Flags().String("area", "", "list only the stations broadcasting in the area (e.g. 'JP13' for Tokyo)")
+	stationCommand.Flags().BoolP("json", "j", false, "print stations as JSON with all the metadata (default)")
+	stationCommand.Flags().BoolP("table", "t", false, "print stations as a table instead of JSON")
+	stationCommand.Flags().BoolP("wide", "w", false, "print the table with the timefree delay, the website, the logo and the banner as well")
 }
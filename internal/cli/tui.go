@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/moutend/go-radiko/internal/tui"
+	"github.com/moutend/go-radiko/pkg/radiko"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var tuiCommand = &cobra.Command{
+	Use:   "tui",
+	Short: "browse, play and record stations in the terminal",
+	RunE:  tuiCommandRunE,
+}
+
+func tuiCommandRunE(cmd *cobra.Command, args []string) error {
+	username := viper.GetString("RADIKO_USERNAME")
+	password := viper.GetString("RADIKO_PASSWORD")
+
+	var cache radiko.SessionCache
+
+	if yes, _ := cmd.Flags().GetBool("no-session-cache"); !yes {
+		var err error
+
+		if cache, err = radiko.NewFileSessionCache(""); err != nil {
+			return err
+		}
+	}
+
+	// The debug messages are not printed, since they break the screen.
+	app := tui.New(func(stationID string) *radiko.Client {
+		client := radiko.New(stationID, username, password)
+
+		if cache != nil {
+			client.SetSessionCache(cache)
+		}
+
+		return client
+	})
+
+	app.Volume, _ = cmd.Flags().GetInt("volume")
+	app.OutputDir, _ = cmd.Flags().GetString("output-dir")
+
+	if app.Volume < 0 || app.Volume > 100 {
+		app.Volume = 100
+	}
+
+	return app.Run(cmd.Context(), os.Stdin, cmd.OutOrStdout())
+}
+
+func init() {
+	RootCommand.AddCommand(tuiCommand)
+
+	tuiCommand.Flags().IntP("volume", "v", 100, "playback volume (min = 0, max = 100)")
+	tuiCommand.Flags().String("output-dir", "", "directory of the recorded files (default is the current directory)")
+}
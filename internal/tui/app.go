@@ -0,0 +1,486 @@
+/*
+Package tui implements the full-screen terminal interface of the radiko command.
+
+The stations in your area are listed with the programs on air, and the program guide of the selected station is shown next to them. The live stream is played with ffplay, and the programs are recorded in the background.
+*/
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+)
+
+type pane int
+
+const (
+	paneStations pane = iota
+	paneGuide
+)
+
+// volumeDelay is the time to wait for the next volume change before restarting the playback.
+const volumeDelay = 500 * time.Millisecond
+
+// App is the terminal interface.
+type App struct {
+	// OutputDir is the directory of the recorded files. The default is the current directory.
+	OutputDir string
+
+	// Volume is the playback volume from 0 to 100.
+	Volume int
+
+	newClient func(stationID string) *radiko.Client
+
+	areaID   string
+	stations radiko.StationSlice
+	guides   map[string]radiko.ProgramSlice
+	day      time.Time
+
+	focus         pane
+	cursor        int
+	stationOffset int
+	guideCursor   int
+	guideOffset   int
+
+	playing    string
+	player     context.CancelFunc
+	playerGen  int
+	volumeGen  int
+	recordings []*recording
+	recorders  sync.WaitGroup
+	message    string
+	quitting   bool
+
+	width  int
+	height int
+
+	events chan func()
+	done   chan struct{}
+}
+
+type recording struct {
+	program radiko.Program
+	file    string
+	live    bool
+	err     error
+	done    bool
+}
+
+// New returns an App. The newClient function returns a client for the station, which is called for every request.
+func New(newClient func(stationID string) *radiko.Client) *App {
+	return &App{
+		Volume:    100,
+		newClient: newClient,
+		guides:    map[string]radiko.ProgramSlice{},
+		events:    make(chan func(), 16),
+		done:      make(chan struct{}),
+	}
+}
+
+// Run shows the interface on the terminal until 'q' is pressed or ctx is canceled.
+//
+// The input must be a terminal. The playback and the recordings in progress are stopped when returning, and the recorded files are completed before it returns.
+func (a *App) Run(ctx context.Context, in *os.File, out io.Writer) error {
+	client := a.newClient("")
+
+	if err := client.GetAreaStations(ctx); err != nil {
+		return err
+	}
+	if len(client.AreaStations) == 0 {
+		return fmt.Errorf("tui: no station in %s", client.AreaName)
+	}
+
+	a.areaID = client.AreaName
+	a.stations = radiko.StationSlice(client.AreaStations)
+
+	restore, err := makeRaw(int(in.Fd()))
+
+	if err != nil {
+		return err
+	}
+
+	defer restore()
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+	defer close(a.done)
+
+	// Use the alternate screen and hide the cursor.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan key, 16)
+
+	go readKeys(in, keys, a.done)
+
+	a.loadGuides(ctx)
+
+	ticker := time.NewTicker(time.Second)
+
+	defer ticker.Stop()
+
+	for {
+		a.width, a.height, err = terminalSize(int(in.Fd()))
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(out, a.render(time.Now()))
+
+		select {
+		case <-ctx.Done():
+			a.quit(cancel, out)
+
+			return ctx.Err()
+		case k := <-keys:
+			if a.handleKey(ctx, k) {
+				a.quit(cancel, out)
+
+				return nil
+			}
+		case fn := <-a.events:
+			fn()
+		case <-ticker.C:
+			if !radiko.BroadcastDate(time.Now()).Equal(a.day) {
+				a.loadGuides(ctx)
+			}
+		}
+	}
+}
+
+func readKeys(in io.Reader, keys chan<- key, done <-chan struct{}) {
+	buf := make([]byte, 64)
+
+	for {
+		n, err := in.Read(buf)
+
+		if err != nil {
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			select {
+			case keys <- k:
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+// quit stops the playback and the recordings, and waits until the recorded files are completed.
+func (a *App) quit(cancel context.CancelFunc, out io.Writer) {
+	a.stop()
+
+	if a.activeRecordings() > 0 {
+		a.message = "finishing recordings..."
+
+		fmt.Fprint(out, a.render(time.Now()))
+	}
+
+	cancel()
+	a.recorders.Wait()
+}
+
+// post runs fn in the event loop.
+func (a *App) post(fn func()) {
+	select {
+	case a.events <- fn:
+	case <-a.done:
+	}
+}
+
+// handleKey handles the key and returns true to quit.
+func (a *App) handleKey(ctx context.Context, k key) bool {
+	if k.code != keyRune || (k.r != 'q' && k.r != 'Q') {
+		a.quitting = false
+	}
+
+	switch {
+	case k.code == keyCtrlC:
+		return true
+	case k.code == keyRune && (k.r == 'q' || k.r == 'Q'):
+		if active := a.activeRecordings(); active > 0 && !a.quitting {
+			a.quitting = true
+			a.message = fmt.Sprintf("%d recordings in progress, press q again to stop them and quit", active)
+
+			return false
+		}
+
+		return true
+	case k.code == keyUp || k.code == keyRune && k.r == 'k':
+		a.move(-1)
+	case k.code == keyDown || k.code == keyRune && k.r == 'j':
+		a.move(1)
+	case k.code == keyTab || k.code == keyLeft || k.code == keyRight || k.code == keyRune && (k.r == 'h' || k.r == 'l'):
+		if a.focus == paneStations {
+			a.focus = paneGuide
+		} else {
+			a.focus = paneStations
+		}
+	case k.code == keyEnter:
+		a.play(ctx, a.selectedStation().ID)
+	case k.code == keyRune && (k.r == 's' || k.r == ' '):
+		a.stop()
+	case k.code == keyRune && (k.r == '+' || k.r == '='):
+		a.changeVolume(ctx, 10)
+	case k.code == keyRune && k.r == '-':
+		a.changeVolume(ctx, -10)
+	case k.code == keyRune && k.r == 'r':
+		a.record(ctx)
+	}
+
+	return false
+}
+
+func (a *App) selectedStation() radiko.Station {
+	return a.stations[a.cursor]
+}
+
+func (a *App) move(delta int) {
+	if a.focus == paneGuide {
+		a.guideCursor = clamp(a.guideCursor+delta, 0, len(a.guides[a.selectedStation().ID])-1)
+
+		return
+	}
+
+	cursor := clamp(a.cursor+delta, 0, len(a.stations)-1)
+
+	if cursor != a.cursor {
+		a.cursor = cursor
+		a.resetGuideCursor()
+	}
+}
+
+// resetGuideCursor moves the guide cursor to the program on air.
+func (a *App) resetGuideCursor() {
+	a.guideCursor = 0
+	a.guideOffset = 0
+
+	programs := a.guides[a.selectedStation().ID]
+
+	for i := range programs {
+		if time.Now().Before(programs[i].End) {
+			a.guideCursor = i
+
+			break
+		}
+	}
+}
+
+// loadGuides fetches today's program guide of each station in the background, the selected station first.
+func (a *App) loadGuides(ctx context.Context) {
+	now := time.Now()
+
+	a.day = radiko.BroadcastDate(now)
+
+	ids := []string{a.selectedStation().ID}
+
+	for _, station := range a.stations {
+		if station.ID != ids[0] {
+			ids = append(ids, station.ID)
+		}
+	}
+
+	go func() {
+		for _, id := range ids {
+			client := a.newClient(id)
+			err := client.GetPrograms(ctx, radiko.BroadcastDate(now))
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			id := id
+			programs := client.Programs
+
+			a.post(func() {
+				if err != nil {
+					a.message = fmt.Sprintf("failed to load the program guide of %s: %v", id, err)
+
+					return
+				}
+
+				a.guides[id] = programs
+
+				if id == a.selectedStation().ID {
+					a.resetGuideCursor()
+				}
+			})
+		}
+	}()
+}
+
+// play starts the live stream of the station, stopping the current one.
+func (a *App) play(ctx context.Context, stationID string) {
+	a.stop()
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	a.playerGen++
+	a.playing = stationID
+	a.player = cancel
+	a.message = ""
+
+	gen := a.playerGen
+	volume := a.Volume
+
+	go func() {
+		err := a.newClient(stationID).Play(ctx, volume)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		a.post(func() {
+			if gen != a.playerGen {
+				return
+			}
+			if err != nil {
+				a.message = fmt.Sprintf("playback of %s stopped: %v", stationID, err)
+			}
+
+			a.playing = ""
+			a.player = nil
+		})
+	}()
+}
+
+func (a *App) stop() {
+	if a.player != nil {
+		a.player()
+	}
+
+	a.playerGen++
+	a.playing = ""
+	a.player = nil
+}
+
+// changeVolume changes the volume and restarts the playback once the volume stops changing.
+func (a *App) changeVolume(ctx context.Context, delta int) {
+	a.Volume = clamp(a.Volume+delta, 0, 100)
+	a.volumeGen++
+
+	if a.playing == "" {
+		return
+	}
+
+	gen := a.volumeGen
+
+	time.AfterFunc(volumeDelay, func() {
+		a.post(func() {
+			if gen == a.volumeGen && a.playing != "" {
+				a.play(ctx, a.playing)
+			}
+		})
+	})
+}
+
+// record starts recording the program on air at the station, or the program selected in the guide.
+//
+// The program on air is recorded from the live stream until it ends, and the past program is recorded from the timefree stream.
+func (a *App) record(ctx context.Context) {
+	station := a.selectedStation()
+	programs := a.guides[station.ID]
+	now := time.Now()
+
+	var program radiko.Program
+
+	if a.focus == paneGuide && a.guideCursor < len(programs) {
+		program = programs[a.guideCursor]
+	} else if p, ok := programs.At(now); ok {
+		program = p
+	} else {
+		a.message = fmt.Sprintf("no program on air at %s", station.ID)
+
+		return
+	}
+	if now.Before(program.Start) {
+		a.message = fmt.Sprintf("%q is not broadcast yet, use 'radiko daemon add' to schedule it", program.Title)
+
+		return
+	}
+
+	live := now.Before(program.End)
+
+	if !live && station.Timefree != 1 {
+		a.message = fmt.Sprintf("%s is not available on timefree", station.ID)
+
+		return
+	}
+
+	for _, r := range a.recordings {
+		if !r.done && r.program.StationID == program.StationID && r.program.Start.Equal(program.Start) {
+			a.message = fmt.Sprintf("%q is already being recorded", program.Title)
+
+			return
+		}
+	}
+
+	r := &recording{
+		program: program,
+		file:    filepath.Join(a.OutputDir, program.FileName(".m4a")),
+		live:    live,
+	}
+
+	a.recordings = append(a.recordings, r)
+	a.message = fmt.Sprintf("recording %q to %s", program.Title, r.file)
+	a.recorders.Add(1)
+
+	go func() {
+		client := a.newClient(program.StationID)
+
+		var err error
+
+		if live {
+			options := []radiko.RecOption{radiko.WithTags(client.ProgramTags(ctx, program)), radiko.WithSidecar(program)}
+			err = client.RecLive(ctx, time.Until(program.End), r.file, options...)
+		} else {
+			err = client.RecProgram(ctx, program, r.file, radiko.WithSidecar(program))
+		}
+
+		// The file is completed here, so quit doesn't need to wait for the event loop.
+		a.recorders.Done()
+
+		a.post(func() {
+			r.done = true
+
+			if err != nil && !errors.Is(err, context.Canceled) {
+				r.err = err
+				a.message = fmt.Sprintf("failed to record %q: %v", program.Title, err)
+			}
+		})
+	}()
+}
+
+func (a *App) activeRecordings() int {
+	n := 0
+
+	for _, r := range a.recordings {
+		if !r.done {
+			n++
+		}
+	}
+
+	return n
+}
+
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+
+	return v
+}
@@ -0,0 +1,69 @@
+package tui
+
+import "unicode/utf8"
+
+// key is a key pressed on the terminal.
+type key struct {
+	code keyCode
+	r    rune
+}
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyEnter
+	keyTab
+	keyEscape
+	keyCtrlC
+)
+
+// parseKeys decodes the input from the terminal in raw mode.
+func parseKeys(b []byte) []key {
+	var keys []key
+
+	for len(b) > 0 {
+		switch b[0] {
+		case 0x03:
+			keys = append(keys, key{code: keyCtrlC})
+			b = b[1:]
+		case '\r', '\n':
+			keys = append(keys, key{code: keyEnter})
+			b = b[1:]
+		case '\t':
+			keys = append(keys, key{code: keyTab})
+			b = b[1:]
+		case 0x1b:
+			// The arrow keys are sent as 'ESC [ A' or 'ESC O A'.
+			if len(b) >= 3 && (b[1] == '[' || b[1] == 'O') {
+				if code, ok := arrowKeys[b[2]]; ok {
+					keys = append(keys, key{code: code})
+					b = b[3:]
+
+					continue
+				}
+			}
+
+			keys = append(keys, key{code: keyEscape})
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+
+			keys = append(keys, key{code: keyRune, r: r})
+			b = b[size:]
+		}
+	}
+
+	return keys
+}
+
+var arrowKeys = map[byte]keyCode{
+	'A': keyUp,
+	'B': keyDown,
+	'C': keyRight,
+	'D': keyLeft,
+}
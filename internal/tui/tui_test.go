@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+	"github.com/moutend/go-radiko/pkg/radiko/radikotest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("j\x1b[A\x1bOB\r\tあ\x1b\x03"))
+
+	require.Equal(t, []key{
+		{code: keyRune, r: 'j'},
+		{code: keyUp},
+		{code: keyDown},
+		{code: keyEnter},
+		{code: keyTab},
+		{code: keyRune, r: 'あ'},
+		{code: keyEscape},
+		{code: keyCtrlC},
+	}, keys)
+}
+
+func TestFit(t *testing.T) {
+	require.Equal(t, "abc  ", fit("abc", 5))
+	require.Equal(t, "ab", fit("abcdef", 2))
+	require.Equal(t, "東京 ", fit("東京ＦＭ", 5))
+	require.Equal(t, "a b", fit("a\nb", 3))
+	require.Equal(t, 8, displayWidth("TOKYO FM"))
+	require.Equal(t, 8, displayWidth("ＴＢＳラ"))
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2026, 10, 18, 21, 30, 0, 0, radiko.JST)
+
+	a := New(nil)
+	a.width = 80
+	a.height = 12
+	a.areaID = "JP13"
+	a.day = radiko.BroadcastDate(now)
+	a.playing = "FMT"
+	a.stations = radiko.StationSlice{{ID: "TBS", Name: "TBSラジオ"}, {ID: "FMT", Name: "TOKYO FM"}}
+	a.guides["FMT"] = radiko.ProgramSlice{
+		{StationID: "FMT", Title: "Morning", Start: now.Add(-3 * time.Hour), End: now.Add(-2 * time.Hour)},
+		{StationID: "FMT", Title: "Night Show", Start: now.Add(-30 * time.Minute), End: now.Add(30 * time.Minute), Performers: []string{"DJ"}},
+	}
+	a.cursor = 1
+	a.recordings = []*recording{{program: a.guides["FMT"][1], file: "out.m4a", live: true}}
+
+	screen := a.render(now)
+
+	require.Contains(t, screen, "JP13 東京都")
+	require.Contains(t, screen, "playing FMT")
+	require.Contains(t, screen, "> FMT        Night Show")
+	require.Contains(t, screen, "*21:00-22:00 Night Show / DJ")
+	require.Contains(t, screen, "2026-10-18")
+	require.Contains(t, screen, "recording, 30m0s left")
+	require.Equal(t, a.height, strings.Count(screen, "\x1b[K"))
+
+	a.width = 10
+
+	require.Contains(t, a.render(now), "terminal")
+}
+
+func TestQuitWhileRecording(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.SegmentDuration = time.Second
+
+	now := time.Now()
+
+	a := New(func(stationID string) *radiko.Client {
+		return radiko.New(stationID, "", "", radiko.WithBaseURL(server.URL), radiko.WithStreamBaseURL(server.URL))
+	})
+	a.width = 80
+	a.height = 12
+	a.OutputDir = t.TempDir()
+	a.stations = radiko.StationSlice{{ID: "FMT", Name: "TOKYO FM"}}
+	a.guides["FMT"] = radiko.ProgramSlice{
+		{StationID: "FMT", Title: "Night Show", Start: now.Add(-time.Minute), End: now.Add(time.Hour)},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	a.record(ctx)
+
+	require.Len(t, a.recordings, 1)
+
+	time.Sleep(2 * time.Second)
+
+	// The live recording in progress is completed before quitting.
+	a.quit(cancel, io.Discard)
+
+	data, err := os.ReadFile(a.recordings[0].file)
+
+	require.NoError(t, err)
+	require.True(t, bytes.Contains(data, []byte("moov")))
+}
@@ -0,0 +1,256 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+	"golang.org/x/text/width"
+)
+
+const (
+	styleReset   = "\x1b[0m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReverse = "\x1b[7m"
+	styleRed     = "\x1b[31m"
+)
+
+// maxRecordingLines limits the number of recordings shown at the bottom.
+const maxRecordingLines = 3
+
+const help = "up/down select  tab switch  enter play  s stop  +/- volume  r record  q quit"
+
+// render returns the escape sequences drawing the whole screen.
+func (a *App) render(now time.Time) string {
+	if a.width < 20 || a.height < 8 {
+		return "\x1b[H\x1b[2J" + fit("terminal is too small", a.width)
+	}
+
+	var lines []string
+
+	status := fmt.Sprintf("vol %d  %s", a.Volume, now.In(radiko.JST).Format("15:04"))
+
+	if a.playing != "" {
+		status = fmt.Sprintf("playing %s  %s", a.playing, status)
+	}
+
+	title := fmt.Sprintf(" radiko  %s %s", a.areaID, radiko.Prefectures[a.areaID])
+
+	lines = append(lines, styleBold+fit(title, a.width-displayWidth(status)-1)+status+styleReset)
+	lines = append(lines, strings.Repeat("-", a.width))
+
+	recordings := a.recordings
+
+	if len(recordings) > maxRecordingLines {
+		recordings = recordings[len(recordings)-maxRecordingLines:]
+	}
+
+	rows := a.height - len(lines) - len(recordings) - 3
+
+	if rows < 1 {
+		rows = 1
+	}
+
+	left := clamp(a.width*2/5, 16, 48)
+	right := a.width - left - 1
+	stationLines := a.renderStations(now, left, rows)
+	guideLines := a.renderGuide(now, right, rows)
+
+	for i := 0; i < rows; i++ {
+		lines = append(lines, stationLines[i]+"|"+guideLines[i])
+	}
+
+	lines = append(lines, strings.Repeat("-", a.width))
+
+	for _, r := range recordings {
+		lines = append(lines, renderRecording(r, now, a.width))
+	}
+
+	lines = append(lines, fit(" "+a.message, a.width))
+	lines = append(lines, styleDim+fit(" "+help, a.width-1)+styleReset)
+
+	var b strings.Builder
+
+	for i, line := range lines {
+		fmt.Fprintf(&b, "\x1b[%d;1H%s\x1b[K", i+1, line)
+	}
+
+	return b.String()
+}
+
+func (a *App) renderStations(now time.Time, width, rows int) []string {
+	a.stationOffset = clamp(a.stationOffset, a.cursor-rows+1, a.cursor)
+
+	lines := make([]string, rows)
+
+	for i := range lines {
+		index := a.stationOffset + i
+
+		if index >= len(a.stations) {
+			lines[i] = fit("", width)
+
+			continue
+		}
+
+		station := a.stations[index]
+		marker := " "
+
+		if station.ID == a.playing {
+			marker = ">"
+		}
+
+		onAir := ""
+
+		if program, ok := a.guides[station.ID].At(now); ok {
+			onAir = program.Title
+		}
+
+		line := fit(fmt.Sprintf("%s %-10s %s", marker, station.ID, onAir), width)
+
+		if index == a.cursor {
+			line = highlight(line, a.focus == paneStations)
+		}
+
+		lines[i] = line
+	}
+
+	return lines
+}
+
+func (a *App) renderGuide(now time.Time, width, rows int) []string {
+	station := a.selectedStation()
+	programs, loaded := a.guides[station.ID]
+
+	lines := make([]string, rows)
+	lines[0] = styleBold + fit(fmt.Sprintf(" %s %s  %s", station.ID, station.Name, a.day.Format("2006-01-02")), width) + styleReset
+
+	if rows < 2 {
+		return lines
+	}
+
+	rows--
+
+	a.guideOffset = clamp(a.guideOffset, a.guideCursor-rows+1, a.guideCursor)
+
+	for i := 0; i < rows; i++ {
+		index := a.guideOffset + i
+
+		if index >= len(programs) {
+			lines[i+1] = fit("", width)
+
+			if i == 0 && !loaded {
+				lines[i+1] = fit(" loading...", width)
+			}
+
+			continue
+		}
+
+		program := programs[index]
+		marker := " "
+
+		if !now.Before(program.Start) && now.Before(program.End) {
+			marker = "*"
+		}
+
+		text := fmt.Sprintf(
+			"%s%s-%s %s",
+			marker,
+			program.Start.In(radiko.JST).Format("15:04"),
+			program.End.In(radiko.JST).Format("15:04"),
+			program.Title,
+		)
+
+		if len(program.Performers) > 0 {
+			text += " / " + strings.Join(program.Performers, ", ")
+		}
+
+		line := fit(text, width)
+
+		if index == a.guideCursor {
+			line = highlight(line, a.focus == paneGuide)
+		}
+
+		lines[i+1] = line
+	}
+
+	return lines
+}
+
+func renderRecording(r *recording, now time.Time, width int) string {
+	status := "downloading"
+
+	switch {
+	case r.err != nil:
+		status = "failed: " + r.err.Error()
+	case r.done:
+		status = "done"
+	case r.live:
+		status = fmt.Sprintf("recording, %s left", r.program.End.Sub(now).Truncate(time.Second))
+	}
+
+	text := fmt.Sprintf(" REC %s %s %s -> %s [%s]", r.program.StationID, r.program.Start.In(radiko.JST).Format("15:04"), r.program.Title, r.file, status)
+
+	if !r.done {
+		return styleRed + fit(text, width) + styleReset
+	}
+
+	return fit(text, width)
+}
+
+// highlight shows the selected line reversed when its pane is focused, or in bold otherwise.
+func highlight(line string, focused bool) string {
+	if focused {
+		return styleReverse + line + styleReset
+	}
+
+	return styleBold + line + styleReset
+}
+
+// fit truncates or pads s to the width in terminal columns. The control characters are replaced with spaces.
+func fit(s string, w int) string {
+	var b strings.Builder
+
+	n := 0
+
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			r = ' '
+		}
+
+		rw := runeWidth(r)
+
+		if n+rw > w {
+			break
+		}
+
+		b.WriteRune(r)
+		n += rw
+	}
+
+	b.WriteString(strings.Repeat(" ", clamp(w-n, 0, w)))
+
+	return b.String()
+}
+
+// displayWidth returns the number of terminal columns occupied by s.
+func displayWidth(s string) int {
+	n := 0
+
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+
+	return n
+}
+
+// runeWidth returns 2 for the wide characters such as kanji and kana, otherwise 1.
+func runeWidth(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,66 @@
+package radiko
+
+import (
+	"fmt"
+)
+
+var samplingFrequencies = []int{
+	96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350,
+}
+
+// ADTSHeader represents a header of an ADTS frame.
+type ADTSHeader struct {
+	// ObjectType is the MPEG-4 audio object type, e.g. 2 is AAC LC.
+	ObjectType             int
+	SamplingFrequencyIndex int
+	ChannelConfiguration   int
+
+	// HeaderLength is 7 or 9 when the header has CRC.
+	HeaderLength int
+
+	// FrameLength is the length of the frame including the header.
+	FrameLength int
+}
+
+// SampleRate returns the sampling frequency in Hz.
+func (h ADTSHeader) SampleRate() int {
+	return samplingFrequencies[h.SamplingFrequencyIndex]
+}
+
+// AudioSpecificConfig returns the decoder configuration used in MP4 container.
+func (h ADTSHeader) AudioSpecificConfig() []byte {
+	return []byte{
+		byte(h.ObjectType<<3 | h.SamplingFrequencyIndex>>1),
+		byte((h.SamplingFrequencyIndex&1)<<7 | h.ChannelConfiguration<<3),
+	}
+}
+
+// ParseADTSHeader parses a header at the beginning of data.
+func ParseADTSHeader(data []byte) (ADTSHeader, error) {
+	if len(data) < 7 {
+		return ADTSHeader{}, fmt.Errorf("radiko: ADTS header is too short")
+	}
+	if data[0] != 0xff || data[1]&0xf6 != 0xf0 {
+		return ADTSHeader{}, fmt.Errorf("radiko: ADTS sync word not found")
+	}
+
+	h := ADTSHeader{
+		ObjectType:             int(data[2]>>6) + 1,
+		SamplingFrequencyIndex: int(data[2]>>2) & 0x0f,
+		ChannelConfiguration:   int(data[2]&0x01)<<2 | int(data[3]>>6),
+		HeaderLength:           7,
+		FrameLength:            int(data[3]&0x03)<<11 | int(data[4])<<3 | int(data[5]>>5),
+	}
+
+	if data[1]&0x01 == 0 {
+		h.HeaderLength = 9
+	}
+	if h.SamplingFrequencyIndex >= len(samplingFrequencies) {
+		return ADTSHeader{}, fmt.Errorf("radiko: invalid sampling frequency index: %d", h.SamplingFrequencyIndex)
+	}
+	if h.FrameLength < h.HeaderLength {
+		return ADTSHeader{}, fmt.Errorf("radiko: invalid ADTS frame length: %d", h.FrameLength)
+	}
+
+	return h, nil
+}
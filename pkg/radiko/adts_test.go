@@ -0,0 +1,37 @@
+package radiko
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseADTSHeader(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "Sample.aac"))
+
+	require.NoError(t, err)
+
+	frames := 0
+
+	for len(data) > 0 {
+		h, err := ParseADTSHeader(data)
+
+		require.NoError(t, err)
+		require.Equal(t, 2, h.ObjectType)
+		require.Equal(t, 24000, h.SampleRate())
+		require.Equal(t, 2, h.ChannelConfiguration)
+		require.Equal(t, 7, h.HeaderLength)
+		require.Equal(t, []byte{0x13, 0x10}, h.AudioSpecificConfig())
+
+		data = data[h.FrameLength:]
+		frames++
+	}
+
+	require.Equal(t, 43, frames)
+
+	_, err = ParseADTSHeader([]byte{0, 1, 2, 3, 4, 5, 6})
+
+	require.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package radiko
+
+import (
+	"context"
+	"fmt"
+)
+
+// Availability tells how a station can be listened to in your area.
+type Availability struct {
+	StationID string
+
+	// InArea is true when the station broadcasts in your area.
+	InArea bool
+
+	// Live is true when the live stream is playable.
+	Live bool
+
+	// Timefree is true when the programs are available on timefree.
+	Timefree bool
+}
+
+// CheckStation tells whether the station is playable live or on timefree in your area.
+//
+// The stations outside your area are playable only by the premium member with the areafree. It returns ErrStationNotFound when no station has the ID.
+func (c *Client) CheckStation(ctx context.Context, stationID string) (Availability, error) {
+	if c.AreaStations == nil {
+		if err := c.GetAreaStations(ctx); err != nil {
+			return Availability{}, fmt.Errorf("radiko: failed to check %s: %w", stationID, err)
+		}
+	}
+
+	a := Availability{StationID: stationID}
+
+	for _, station := range c.AreaStations {
+		if station.ID == stationID {
+			a.InArea = true
+			a.Live = true
+			a.Timefree = station.Timefree == 1
+
+			return a, nil
+		}
+	}
+	if c.AllStations == nil {
+		if err := c.GetAllStations(ctx); err != nil {
+			return Availability{}, fmt.Errorf("radiko: failed to check %s: %w", stationID, err)
+		}
+	}
+	for _, station := range c.AllStations {
+		if station.ID != stationID {
+			continue
+		}
+		if c.isPremium() {
+			a.Live = station.Areafree == 1
+			a.Timefree = a.Live && station.Timefree == 1
+		}
+
+		return a, nil
+	}
+
+	return Availability{}, fmt.Errorf("radiko: %s: %w", stationID, ErrStationNotFound)
+}
+
+// isPremium returns true when the client is logged in, or will log in, as a premium member.
+func (c *Client) isPremium() bool {
+	if s := c.session(); s != nil {
+		return s.MemberType == MemberTypePremium
+	}
+
+	return c.username != "" && c.password != ""
+}
@@ -0,0 +1,123 @@
+package radiko
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// SessionCache stores sessions across processes.
+//
+// Load returns nil without error when the session is not found.
+type SessionCache interface {
+	Load(key string) (*Session, error)
+	Store(key string, s *Session) error
+	Delete(key string) error
+}
+
+// FileSessionCache stores sessions as JSON files which only the owner can read.
+type FileSessionCache struct {
+	dir string
+}
+
+// NewFileSessionCache returns a cache stored in dir.
+//
+// If dir is empty, 'go-radiko/sessions' under the user cache directory is used.
+func NewFileSessionCache(dir string) (*FileSessionCache, error) {
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+
+		if err != nil {
+			return nil, fmt.Errorf("radiko: failed to get user cache directory: %w", err)
+		}
+
+		dir = filepath.Join(cacheDir, "go-radiko", "sessions")
+	}
+
+	return &FileSessionCache{dir: dir}, nil
+}
+
+// Load loads the session stored with key.
+func (c *FileSessionCache) Load(key string) (*Session, error) {
+	data, err := os.ReadFile(c.path(key))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("radiko: failed to read session: %w", err)
+	}
+
+	var s Session
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("radiko: failed to parse session: %w", err)
+	}
+
+	return &s, nil
+}
+
+// Store stores the session with key.
+func (c *FileSessionCache) Store(key string, s *Session) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("radiko: failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(s)
+
+	if err != nil {
+		return fmt.Errorf("radiko: failed to encode session: %w", err)
+	}
+
+	// Write to a temporary file first so that other processes never read a partial file.
+	file, err := os.CreateTemp(c.dir, ".session-*")
+
+	if err != nil {
+		return fmt.Errorf("radiko: failed to create session file: %w", err)
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+
+		return fmt.Errorf("radiko: failed to write session: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("radiko: failed to write session: %w", err)
+	}
+	if err := os.Rename(file.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("radiko: failed to write session: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes the session stored with key.
+func (c *FileSessionCache) Delete(key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("radiko: failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+func (c *FileSessionCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// sessionKey returns a cache key of the session for the account and the area.
+func sessionKey(username, areaName string) string {
+	if username == "" {
+		username = "anonymous"
+	}
+
+	return username + "@" + areaName
+}
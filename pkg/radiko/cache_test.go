@@ -0,0 +1,56 @@
+package radiko
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSessionCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+
+	cache, err := NewFileSessionCache(dir)
+
+	require.NoError(t, err)
+
+	s, err := cache.Load("anonymous@JP13")
+
+	require.NoError(t, err)
+	require.Nil(t, s)
+
+	expected := &Session{
+		AuthToken:  "token",
+		AreaName:   "JP13",
+		AExp:       "aexp",
+		MemberType: MemberTypeNormal,
+		ExpiresAt:  time.Date(2023, 10, 17, 12, 0, 0, 0, time.UTC),
+	}
+
+	require.NoError(t, cache.Store("anonymous@JP13", expected))
+
+	s, err = cache.Load("anonymous@JP13")
+
+	require.NoError(t, err)
+	require.Equal(t, expected, s)
+
+	entries, err := os.ReadDir(dir)
+
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	info, err := entries[0].Info()
+
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, cache.Delete("anonymous@JP13"))
+	require.NoError(t, cache.Delete("anonymous@JP13"))
+
+	s, err = cache.Load("anonymous@JP13")
+
+	require.NoError(t, err)
+	require.Nil(t, s)
+}
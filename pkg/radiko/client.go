@@ -7,14 +7,17 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
-	"os/exec"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -25,6 +28,19 @@ type Client struct {
 	password string
 
 	debug *log.Logger
+	cache SessionCache
+
+	httpClient    *http.Client
+	baseURL       string
+	streamBaseURL string
+	userAgent     string
+	reconnect     ReconnectPolicy
+
+	// sessionMu guards Session, which is read and discarded by the concurrent downloads.
+	sessionMu sync.Mutex
+
+	// authMu serializes the authentication, so that the downloads rejected at the same time authenticate once.
+	authMu sync.Mutex
 
 	// AExp corresponds to the cookie value named 'a_exp'.
 	AExp string
@@ -56,31 +72,189 @@ type Client struct {
 	// AllStations holds a result of GetAllStations method.
 	AllStations []Station
 
+	// Regions holds a result of GetAllStations method, with the stations grouped by region.
+	Regions []Region
+
+	// AreaStations holds a result of GetAreaStations method.
+	AreaStations []Station
+
+	// Programs holds a result of GetPrograms or GetWeeklyPrograms method.
+	Programs ProgramSlice
+
+	// NowOnAir holds a result of GetNowOnAir method.
+	NowOnAir Program
+
+	// OnAirSongs holds a result of GetOnAirSongs method.
+	OnAirSongs []Song
+
 	// PlaylistM3U8s holds a result of Playlist method.
 	PlaylistM3U8s []PlaylistM3U8
+
+	// Session holds a result of Authenticate method.
+	Session *Session
+}
+
+// Option configures a client.
+type Option func(*Client)
+
+// WithHTTPClient sets an HTTP client used for all requests, e.g. to use a proxy, a timeout or a cookie jar.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithBaseURL sets the base URL of the radiko.jp API. The default is 'https://radiko.jp'.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithStreamBaseURL sets the base URL of the HLS streams. The default is 'https://rd-wowza-radiko.radiko-cf.com'.
+func WithStreamBaseURL(streamBaseURL string) Option {
+	return func(c *Client) {
+		c.streamBaseURL = strings.TrimSuffix(streamBaseURL, "/")
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with all requests.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// RecOption configures a recording.
+type RecOption func(*recConfig)
+
+type recConfig struct {
+	concurrency int
+	resume      bool
+	tags        *Tags
+	noTags      bool
+	sidecar     *Program
+}
+
+// WithConcurrency sets the number of segments downloaded at the same time by Rec. The default is 1.
+//
+// It has no effect on the live stream because its segments are published in real time.
+func WithConcurrency(n int) RecOption {
+	return func(c *recConfig) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithResume makes Rec resumable. The downloaded audio is kept in '<outputFile>.part' with the state in '<outputFile>.part.json', and Rec continues from the last completed segment when it is called again with the same station, date and length.
+//
+// The part file and the state are removed after the output file is completed.
+func WithResume() RecOption {
+	return func(c *recConfig) {
+		c.resume = true
+	}
+}
+
+// WithTags sets the metadata embedded into the output file. RecProgram embeds the tags of the program by default.
+func WithTags(tags Tags) RecOption {
+	return func(c *recConfig) {
+		c.tags = &tags
+	}
+}
+
+// WithoutTags disables embedding the metadata into the output file.
+func WithoutTags() RecOption {
+	return func(c *recConfig) {
+		c.noTags = true
+	}
+}
+
+// WithSidecar saves the program as the metadata sidecar of the output file. See Recording and SidecarPath.
+func WithSidecar(program Program) RecOption {
+	return func(c *recConfig) {
+		c.sidecar = &program
+	}
+}
+
+// reader returns a reader of the rest of the stream.
+func (c recConfig) reader(stream *Stream) segmentReader {
+	if c.concurrency > 1 {
+		return stream.parallel(c.concurrency)
+	}
+
+	return stream
+}
+
+// outputTags returns the tags embedded into the output file, or nil.
+func (c recConfig) outputTags() *Tags {
+	if c.noTags {
+		return nil
+	}
+
+	return c.tags
+}
+
+// writeSidecar writes the metadata sidecar of the output file if required.
+func (c recConfig) writeSidecar(outputFile string) error {
+	if c.sidecar == nil {
+		return nil
+	}
+
+	return writeRecording(outputFile, Recording{Program: *c.sidecar, RecordedAt: time.Now()})
+}
+
+func newRecConfig(options []RecOption) recConfig {
+	config := recConfig{concurrency: 1}
+
+	for _, option := range options {
+		option(&config)
+	}
+
+	return config
 }
 
 // New returns a client.
-func New(station, username, password string) *Client {
+func New(station, username, password string, options ...Option) *Client {
 	now := time.Now()
 	sum := md5.Sum([]byte(fmt.Sprint(now.Unix())))
 
-	return &Client{
-		station:  station,
-		username: username,
-		password: password,
-		debug:    log.New(io.Discard, "", 0),
-		AExp:     hex.EncodeToString(sum[:]),
+	c := &Client{
+		station:       station,
+		username:      username,
+		password:      password,
+		debug:         log.New(io.Discard, "", 0),
+		httpClient:    http.DefaultClient,
+		baseURL:       "https://radiko.jp",
+		streamBaseURL: "https://rd-wowza-radiko.radiko-cf.com",
+		reconnect:     DefaultReconnectPolicy,
+		AExp:          hex.EncodeToString(sum[:]),
 	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	return c.httpClient.Do(req)
 }
 
 // GetAllStations fetches a list of all radio stations.
 //
-// The result is stored in AllStations field.
+// The result is stored in AllStations and Regions fields.
 //
 // You can call this method without any authentication.
 func (c *Client) GetAllStations(ctx context.Context) error {
-	const u = "https://radiko.jp/v3/station/region/full.xml"
+	u := c.baseURL + "/v3/station/region/full.xml"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 
@@ -88,7 +262,7 @@ func (c *Client) GetAllStations(ctx context.Context) error {
 		return fmt.Errorf("stations: failed to create request: %w", err)
 	}
 
-	res, err := (&http.Client{}).Do(req)
+	res, err := c.do(req)
 
 	if err != nil {
 		return fmt.Errorf("stations: failed to fetch full.xml: %w", err)
@@ -98,6 +272,10 @@ func (c *Client) GetAllStations(ctx context.Context) error {
 
 	c.debug.Println("stations: status code:", res.Status)
 
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("stations: %w", &HTTPStatusError{Step: "stations", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
 	body := &bytes.Buffer{}
 
 	data, err := io.ReadAll(io.TeeReader(res.Body, body))
@@ -108,24 +286,256 @@ func (c *Client) GetAllStations(ctx context.Context) error {
 
 	c.debug.Printf("stations: response body: %q\n", data)
 
-	allStations, err := ParseFullStationXML(body)
+	regions, err := ParseRegionXML(body)
 
 	if err != nil {
 		return fmt.Errorf("stations: failed to parse response body: %w", err)
 	}
 
-	c.AllStations = allStations
+	c.Regions = regions
+	c.AllStations = nil
+
+	for i := range regions {
+		c.AllStations = append(c.AllStations, regions[i].Stations...)
+	}
+
+	return nil
+}
+
+// GetAreaStations fetches a list of radio stations in your area.
+//
+// The result is stored in AreaStations field. The area is detected from your IP unless AreaName field is set.
+func (c *Client) GetAreaStations(ctx context.Context) error {
+	if c.AreaName == "" {
+		if err := c.GetAreaName(ctx); err != nil {
+			return err
+		}
+	}
+
+	u := c.baseURL + "/v3/station/list/" + c.AreaName + ".xml"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	if err != nil {
+		return fmt.Errorf("area stations: failed to create request: %w", err)
+	}
+
+	res, err := c.do(req)
+
+	if err != nil {
+		return fmt.Errorf("area stations: failed to fetch %s.xml: %w", c.AreaName, err)
+	}
+
+	defer res.Body.Close()
+
+	c.debug.Println("area stations: status code:", res.Status)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("area stations: %w", &HTTPStatusError{Step: "area stations", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
+	areaStations, err := ParseAreaStationXML(res.Body)
+
+	if err != nil {
+		return fmt.Errorf("area stations: failed to parse response body: %w", err)
+	}
+
+	c.AreaStations = areaStations
+
+	return nil
+}
+
+// GetPrograms fetches the program guide of the station on the specified date.
+//
+// The result is stored in Programs field. Note that a day on radiko.jp starts at 05:00 JST.
+//
+// You can call this method without any authentication.
+func (c *Client) GetPrograms(ctx context.Context, date time.Time) error {
+	u := fmt.Sprintf("%s/v3/program/station/date/%s/%s.xml", c.baseURL, date.In(JST).Format("20060102"), c.station)
+
+	programs, err := c.getPrograms(ctx, "programs", u)
+
+	if err != nil {
+		return err
+	}
+
+	c.Programs = programs
+
+	return nil
+}
+
+// GetWeeklyPrograms fetches the program guide of the station for the week.
+//
+// The result is stored in Programs field.
+//
+// You can call this method without any authentication.
+func (c *Client) GetWeeklyPrograms(ctx context.Context) error {
+	u := fmt.Sprintf("%s/v3/program/station/weekly/%s.xml", c.baseURL, c.station)
+
+	programs, err := c.getPrograms(ctx, "weekly programs", u)
+
+	if err != nil {
+		return err
+	}
+
+	c.Programs = programs
+
+	return nil
+}
+
+// GetGuide fetches the programs of the broadcast date, or the programs of the weekly guide from the date if week is true.
+//
+// The result is stored in Programs field.
+//
+// You can call this method without any authentication.
+func (c *Client) GetGuide(ctx context.Context, date time.Time, week bool) error {
+	if !week {
+		return c.GetPrograms(ctx, date)
+	}
+	if err := c.GetWeeklyPrograms(ctx); err != nil {
+		return err
+	}
+
+	// The weekly guide contains the past days as well.
+	date = date.In(JST)
+	start := time.Date(date.Year(), date.Month(), date.Day(), 5, 0, 0, 0, JST)
+
+	var programs ProgramSlice
+
+	for _, program := range c.Programs {
+		if !program.Start.Before(start) {
+			programs = append(programs, program)
+		}
+	}
+
+	c.Programs = programs
+
+	return nil
+}
+
+// GetNowOnAir fetches the program currently on air at the station.
+//
+// The result is stored in NowOnAir field.
+//
+// You can call this method without any authentication.
+func (c *Client) GetNowOnAir(ctx context.Context) error {
+	now := time.Now()
+
+	u := fmt.Sprintf("%s/v3/program/station/date/%s/%s.xml", c.baseURL, BroadcastDate(now).Format("20060102"), c.station)
+
+	programs, err := c.getPrograms(ctx, "now on air", u)
+
+	if err != nil {
+		return err
+	}
+
+	program, ok := programs.At(now)
+
+	if !ok {
+		return fmt.Errorf("now on air: %w", ErrProgramNotFound)
+	}
+
+	c.NowOnAir = program
+
+	return nil
+}
+
+// GetOnAirSongs fetches the history of songs played on the station.
+//
+// The result is stored in OnAirSongs field. The latest song comes first.
+//
+// You can call this method without any authentication.
+func (c *Client) GetOnAirSongs(ctx context.Context) error {
+	u := fmt.Sprintf("%s/v3/feed/pc/noa/%s.xml", c.baseURL, c.station)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	if err != nil {
+		return fmt.Errorf("songs: failed to create request: %w", err)
+	}
+
+	res, err := c.do(req)
+
+	if err != nil {
+		return fmt.Errorf("songs: error response: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	c.debug.Println("songs: status code:", res.Status)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("songs: %w", &HTTPStatusError{Step: "songs", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
+	body := &bytes.Buffer{}
+
+	data, err := io.ReadAll(io.TeeReader(res.Body, body))
+
+	if err != nil {
+		return fmt.Errorf("songs: failed to copy response body: %w", err)
+	}
+
+	c.debug.Printf("songs: response body: %q\n", data)
+
+	songs, err := ParseNoaXML(body)
+
+	if err != nil {
+		return fmt.Errorf("songs: failed to parse response body: %w", err)
+	}
+
+	c.OnAirSongs = songs
 
 	return nil
 }
 
+func (c *Client) getPrograms(ctx context.Context, step, u string) (ProgramSlice, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to create request: %w", step, err)
+	}
+
+	res, err := c.do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: error response: %w", step, err)
+	}
+
+	defer res.Body.Close()
+
+	c.debug.Printf("%s: status code: %s\n", step, res.Status)
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %w", step, &HTTPStatusError{Step: step, StatusCode: res.StatusCode, Status: res.Status})
+	}
+
+	body := &bytes.Buffer{}
+
+	data, err := io.ReadAll(io.TeeReader(res.Body, body))
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to copy response body: %w", step, err)
+	}
+
+	c.debug.Printf("%s: response body: %q\n", step, data)
+
+	programs, err := ParseProgramXML(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to parse response body: %w", step, err)
+	}
+
+	return programs, nil
+}
+
 // GetAreaName fetches an area name based off your IP.
 //
 // The result is stored AreaName field.
 //
 // You can call this method without any authentication.
 func (c *Client) GetAreaName(ctx context.Context) error {
-	const u = "https://radiko.jp/area"
+	u := c.baseURL + "/area"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 
@@ -133,7 +543,7 @@ func (c *Client) GetAreaName(ctx context.Context) error {
 		return fmt.Errorf("area: failed to create request: %w", err)
 	}
 
-	res, err := (&http.Client{}).Do(req)
+	res, err := c.do(req)
 
 	if err != nil {
 		return fmt.Errorf("area: error response: %w", err)
@@ -143,6 +553,10 @@ func (c *Client) GetAreaName(ctx context.Context) error {
 
 	c.debug.Println("area: status code:", res.Status)
 
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("area: %w", &HTTPStatusError{Step: "area", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
 	body, err := io.ReadAll(res.Body)
 
 	if err != nil {
@@ -164,7 +578,7 @@ func (c *Client) GetAreaName(ctx context.Context) error {
 	c.debug.Printf("area: your area name: %q\n", c.AreaName)
 
 	if !strings.HasPrefix(c.AreaName, "JP") {
-		return fmt.Errorf("area: your IP is geo-restricted")
+		return fmt.Errorf("area: %w", ErrGeoRestricted)
 	}
 
 	return nil
@@ -174,7 +588,7 @@ func (c *Client) GetAreaName(ctx context.Context) error {
 //
 // You can call this method without any authentication.
 func (c *Client) GetSeed(ctx context.Context) error {
-	const u = "https://radiko.jp/apps/js/playerCommon.js"
+	u := c.baseURL + "/apps/js/playerCommon.js"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 
@@ -182,7 +596,7 @@ func (c *Client) GetSeed(ctx context.Context) error {
 		return fmt.Errorf("seed: failed to create request: %w", err)
 	}
 
-	res, err := (&http.Client{}).Do(req)
+	res, err := c.do(req)
 
 	if err != nil {
 		return fmt.Errorf("seed: error response: %w", err)
@@ -192,6 +606,10 @@ func (c *Client) GetSeed(ctx context.Context) error {
 
 	c.debug.Println("seed: status code:", res.Status)
 
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("seed: %w", &HTTPStatusError{Step: "seed", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
 	body, err := io.ReadAll(res.Body)
 
 	if err != nil {
@@ -234,7 +652,7 @@ func (c *Client) Login(ctx context.Context) error {
 	values.Add("mail", c.username)
 	values.Add("pass", c.password)
 
-	const u = "https://radiko.jp/v4/api/member/login"
+	u := c.baseURL + "/v4/api/member/login"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewBufferString(values.Encode()))
 
@@ -244,7 +662,7 @@ func (c *Client) Login(ctx context.Context) error {
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	res, err := (&http.Client{}).Do(req)
+	res, err := c.do(req)
 
 	if err != nil {
 		return fmt.Errorf("login: error response: %w", err)
@@ -255,7 +673,7 @@ func (c *Client) Login(ctx context.Context) error {
 	c.debug.Println("login: status code:", res.Status)
 
 	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("login: failed to login")
+		return fmt.Errorf("login: %w: %w", ErrLoginFailed, &HTTPStatusError{Step: "login", StatusCode: res.StatusCode, Status: res.Status})
 	}
 
 	var response struct {
@@ -284,7 +702,7 @@ func (c *Client) Check(ctx context.Context) error {
 		return nil
 	}
 
-	const u = "https://radiko.jp/ap/member/webapi/v2/member/login/check"
+	u := c.baseURL + "/ap/member/webapi/v2/member/login/check"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 
@@ -292,7 +710,7 @@ func (c *Client) Check(ctx context.Context) error {
 		return fmt.Errorf("check: failed to create request: %w", err)
 	}
 
-	res, err := (&http.Client{}).Do(req)
+	res, err := c.do(req)
 
 	if err != nil {
 		return fmt.Errorf("check: error response: %w", err)
@@ -318,7 +736,7 @@ func (c *Client) Check(ctx context.Context) error {
 
 // Auth1 performs a authentication step required at first.
 func (c *Client) Auth1(ctx context.Context) error {
-	const u = "https://radiko.jp/v2/api/auth1"
+	u := c.baseURL + "/v2/api/auth1"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 
@@ -342,7 +760,7 @@ func (c *Client) Auth1(ctx context.Context) error {
 	req.Header.Set("x-radiko-device", "pc")
 	req.Header.Set("x-radiko-app-version", "0.0.1")
 
-	res, err := (&http.Client{}).Do(req)
+	res, err := c.do(req)
 
 	if err != nil {
 		return fmt.Errorf("auth1: error response: %w", err)
@@ -352,10 +770,14 @@ func (c *Client) Auth1(ctx context.Context) error {
 
 	c.debug.Println("auth1: status code:", res.Status)
 
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth1: %w: %w", ErrAuthFailed, &HTTPStatusError{Step: "auth1", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
 	c.AuthToken = res.Header.Get("X-Radiko-Authtoken")
 
 	if c.AuthToken == "" {
-		return fmt.Errorf("auth1: empty auth token")
+		return fmt.Errorf("auth1: %w: empty auth token", ErrAuthFailed)
 	}
 
 	keyLength, err := strconv.ParseInt(res.Header.Get(`X-Radiko-KeyLength`), 10, 64)
@@ -364,7 +786,7 @@ func (c *Client) Auth1(ctx context.Context) error {
 		return fmt.Errorf("auth1: failed to parse key length: %w", err)
 	}
 	if keyLength == 0 {
-		return fmt.Errorf("auth1: key length is 0")
+		return fmt.Errorf("auth1: %w: key length is 0", ErrAuthFailed)
 	}
 
 	keyOffset, err := strconv.ParseInt(res.Header.Get(`X-Radiko-KeyOffset`), 10, 64)
@@ -373,7 +795,7 @@ func (c *Client) Auth1(ctx context.Context) error {
 		return fmt.Errorf("auth1: failed to parse key offset: %w", err)
 	}
 	if int(keyOffset+keyLength) > len(c.FullKey) {
-		return fmt.Errorf("auth1: invalid key length and offset: length=%v, offset=%v", keyLength, keyOffset)
+		return fmt.Errorf("auth1: %w: invalid key length and offset: length=%v, offset=%v", ErrAuthFailed, keyLength, keyOffset)
 	}
 
 	c.KeyLength = int(keyLength)
@@ -390,7 +812,7 @@ func (c *Client) Auth1(ctx context.Context) error {
 
 // Auth2 performs a authentication step required at second.
 func (c *Client) Auth2(ctx context.Context) error {
-	const u = "https://radiko.jp/v2/api/auth2"
+	u := c.baseURL + "/v2/api/auth2"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 
@@ -414,7 +836,7 @@ func (c *Client) Auth2(ctx context.Context) error {
 	req.Header.Set("x-radiko-user", "dummy_user")
 	req.Header.Set("x-radiko-device", "pc")
 
-	res, err := (&http.Client{}).Do(req)
+	res, err := c.do(req)
 
 	if err != nil {
 		return fmt.Errorf("auth2: error response: %w", err)
@@ -424,12 +846,16 @@ func (c *Client) Auth2(ctx context.Context) error {
 
 	c.debug.Println("auth2: status code:", res.Status)
 
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth2: %w: %w", ErrAuthFailed, &HTTPStatusError{Step: "auth2", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
 	return nil
 }
 
 // Playlist fetches a list of URL used for generating an HLS source.
 func (c *Client) Playlist(ctx context.Context) error {
-	u := fmt.Sprintf("https://radiko.jp/v3/station/stream/pc_html5/%s.xml", c.station)
+	u := fmt.Sprintf("%s/v3/station/stream/pc_html5/%s.xml", c.baseURL, c.station)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 
@@ -449,7 +875,7 @@ func (c *Client) Playlist(ctx context.Context) error {
 
 	req.Header.Set("Cookie", cookie)
 
-	res, err := (&http.Client{}).Do(req)
+	res, err := c.do(req)
 
 	if err != nil {
 		return fmt.Errorf("playlist: error response: %w", err)
@@ -459,6 +885,10 @@ func (c *Client) Playlist(ctx context.Context) error {
 
 	c.debug.Println("playlist: status code:", res.Status)
 
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("playlist: %w", &HTTPStatusError{Step: "playlist", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
 	body := &bytes.Buffer{}
 
 	data, err := io.ReadAll(io.TeeReader(res.Body, body))
@@ -480,121 +910,414 @@ func (c *Client) Playlist(ctx context.Context) error {
 	return nil
 }
 
-// Play launches ffmpeg command which plays live streaming.
-func (c *Client) Play(ctx context.Context, playbackVolume int) error {
+// Authenticate performs all steps of authentication and returns the session.
+//
+// The result is stored in Session field. Play and Rec reuse the session until it expires.
+func (c *Client) Authenticate(ctx context.Context) (*Session, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	return c.newSession(ctx)
+}
+
+// newSession is Authenticate without authMu.
+func (c *Client) newSession(ctx context.Context) (*Session, error) {
 	if err := c.GetAreaName(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to get area name: %w", err)
+		return nil, fmt.Errorf("radiko: failed to get area name: %w", err)
 	}
 	if err := c.GetSeed(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to get seed: %w", err)
+		return nil, fmt.Errorf("radiko: failed to get seed: %w", err)
 	}
 	if err := c.Login(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to login: %w", err)
+		return nil, fmt.Errorf("radiko: failed to login: %w", err)
 	}
 	if err := c.Check(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to check member status: %w", err)
+		return nil, fmt.Errorf("radiko: failed to check member status: %w", err)
 	}
 	if err := c.Auth1(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to complete first authentication: %w", err)
+		return nil, fmt.Errorf("radiko: failed to complete first authentication: %w", err)
 	}
 	if err := c.Auth2(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to complete second authentication: %w", err)
+		return nil, fmt.Errorf("radiko: failed to complete second authentication: %w", err)
 	}
-	if err := c.Playlist(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to get playlist: %w", err)
+
+	memberType := MemberTypeNormal
+
+	if c.username != "" && c.password != "" {
+		memberType = MemberTypePremium
 	}
 
-	headers := fmt.Sprintf("X-Radiko-AuthToken: %s", c.AuthToken)
-	input := fmt.Sprintf(
-		"https://rd-wowza-radiko.radiko-cf.com/so/playlist.m3u8?station_id=%s&l=15&lsid=%s&type=c",
-		c.station,
-		c.AExp,
-	)
+	session := &Session{
+		AuthToken:     c.AuthToken,
+		AreaName:      c.AreaName,
+		AExp:          c.AExp,
+		RadikoSession: c.RadikoSession,
+		MemberType:    memberType,
+		ExpiresAt:     time.Now().Add(sessionLifetime),
+	}
 
-	ffmpeg := exec.CommandContext(
-		ctx, "ffmpeg",
-		"-headers", headers,
-		"-i", input,
-		"-f", "matroska", "-",
-	)
-	ffplay := exec.CommandContext(
-		ctx, "ffplay",
-		"-volume", fmt.Sprint(playbackVolume),
-		"-i", "-",
-	)
+	c.sessionMu.Lock()
+	c.Session = session
+	c.sessionMu.Unlock()
+
+	if c.cache != nil {
+		if err := c.cache.Store(sessionKey(c.username, c.AreaName), session); err != nil {
+			c.debug.Println("authenticate: failed to store session:", err)
+		}
+	}
+
+	return session, nil
+}
+
+// SetSession sets a session obtained by Authenticate method, e.g. of another client.
+func (c *Client) SetSession(s *Session) {
+	if s == nil {
+		return
+	}
+
+	c.sessionMu.Lock()
+	c.Session = s
+	c.sessionMu.Unlock()
+
+	c.AuthToken = s.AuthToken
+	c.AreaName = s.AreaName
+	c.AExp = s.AExp
+	c.RadikoSession = s.RadikoSession
+}
+
+// SetStation sets the station to play or record.
+func (c *Client) SetStation(station string) {
+	c.station = station
+}
+
+// SetSessionCache sets a cache which Play and Rec consult before authentication.
+func (c *Client) SetSessionCache(cache SessionCache) {
+	c.cache = cache
+}
+
+// authenticate runs Authenticate method unless the client or the cache has a valid session.
+//
+// It reports whether the existing session is reused.
+func (c *Client) authenticate(ctx context.Context) (bool, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	if s := c.session(); s != nil && !s.Expired(time.Now()) {
+		c.debug.Println("authenticate: reuse session")
+
+		return true, nil
+	}
+	if c.cache != nil {
+		if err := c.GetAreaName(ctx); err != nil {
+			return false, fmt.Errorf("radiko: failed to get area name: %w", err)
+		}
+
+		s, err := c.cache.Load(sessionKey(c.username, c.AreaName))
+
+		if err != nil {
+			c.debug.Println("authenticate: failed to load session:", err)
+		}
+		if s != nil && !s.Expired(time.Now()) {
+			c.debug.Println("authenticate: reuse cached session")
 
-	pr, pw := io.Pipe()
+			c.SetSession(s)
 
-	ffmpeg.Stdout = pw
-	ffplay.Stdin = pr
+			return true, nil
+		}
+	}
+	if _, err := c.newSession(ctx); err != nil {
+		return false, err
+	}
 
-	defer pw.Close()
-	defer pr.Close()
+	return false, nil
+}
 
-	if err := ffmpeg.Start(); err != nil {
-		return fmt.Errorf("radiko: failed to start ffmpeg command: %w", err)
+// openStream authenticates and opens the stream located at the URL built by fn.
+//
+// When the reused session is rejected, it authenticates again. When the new session is rejected too, the station is not available in your area.
+func (c *Client) openStream(ctx context.Context, fn func() string) (*Stream, error) {
+	reused, err := c.authenticate(ctx)
+
+	if err != nil {
+		return nil, err
 	}
-	if err := ffplay.Start(); err != nil {
-		return fmt.Errorf("radiko: failed to start ffplay command: %w", err)
+
+	stream := c.NewStream(ctx, fn())
+	err = stream.reload()
+
+	if isForbidden(err) && reused {
+		c.debug.Println("stream: session rejected, authenticate again")
+
+		// The rejected session has been discarded by the request, so a new session is issued unless another download has done it.
+		if _, err := c.authenticate(ctx); err != nil {
+			return nil, err
+		}
+
+		stream = c.NewStream(ctx, fn())
+		err = stream.reload()
 	}
-	if err := ffmpeg.Wait(); err != nil {
-		return fmt.Errorf("radiko: ffmpeg: unexpected error: %w", err)
+	if isForbidden(err) {
+		return nil, fmt.Errorf("radiko: %s: %w: %w", c.station, ErrStationNotInArea, err)
 	}
-	if err := ffplay.Wait(); err != nil {
-		return fmt.Errorf("radiko: ffplay: unexpected error: %w", err)
+	if err != nil {
+		return nil, fmt.Errorf("radiko: failed to open stream: %w", err)
 	}
 
-	return nil
+	return stream, nil
 }
 
-// Rec launches ffmpeg command and records a specified radio program.
-func (c *Client) Rec(ctx context.Context, date time.Time, length time.Duration, outputFile string) error {
-	if err := c.GetAreaName(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to get area name: %w", err)
+func isForbidden(err error) bool {
+	var statusErr *HTTPStatusError
+
+	if !errors.As(err, &statusErr) {
+		return false
 	}
-	if err := c.GetSeed(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to get seed: %w", err)
+
+	return statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden
+}
+
+// session returns the current session, or nil.
+func (c *Client) session() *Session {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	return c.Session
+}
+
+// authToken returns the token sent with the stream requests. Without a session, it waits for the authentication in progress.
+func (c *Client) authToken() string {
+	if s := c.session(); s != nil {
+		return s.AuthToken
 	}
-	if err := c.Login(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to login: %w", err)
+
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	return c.AuthToken
+}
+
+// invalidateSession discards the session whose token was rejected by radiko.jp.
+//
+// The session issued after the token was sent is kept, because the concurrent downloads may be rejected after the authentication.
+func (c *Client) invalidateSession(token string) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	if c.Session == nil || c.Session.AuthToken != token {
+		return
 	}
-	if err := c.Check(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to check member status: %w", err)
+	if c.cache != nil {
+		if err := c.cache.Delete(sessionKey(c.username, c.Session.AreaName)); err != nil {
+			c.debug.Println("authenticate: failed to delete session:", err)
+		}
 	}
-	if err := c.Auth1(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to complete first authentication: %w", err)
+
+	c.Session = nil
+}
+
+// Play downloads the live stream and plays it with ffplay command.
+func (c *Client) Play(ctx context.Context, playbackVolume int) error {
+	return c.PlayTo(ctx, FFplaySink(playbackVolume))
+}
+
+// PlayTo writes the live stream to the sink until the sink finishes or ctx is canceled.
+func (c *Client) PlayTo(ctx context.Context, sink AudioSink) error {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+
+	stream, err := c.openLiveStream(ctx)
+
+	if err != nil {
+		return err
 	}
-	if err := c.Auth2(ctx); err != nil {
-		return fmt.Errorf("radiko: failed to complete second authentication: %w", err)
+
+	w, err := sink.Start(ctx)
+
+	if err != nil {
+		return fmt.Errorf("radiko: failed to start audio sink: %w", err)
 	}
 
-	header := fmt.Sprintf("X-Radiko-AuthToken: %s", c.AuthToken)
-	input := fmt.Sprintf(
-		"https://rd-wowza-radiko.radiko-cf.com/tf/playlist.m3u8?station_id=%s&start_at=%s&ft=%s&end_at=%s&to=%s&l=15&lsid=%s&type=c",
-		c.station,
-		date.Format("20060102150405"),
-		date.Format("20060102150405"),
-		date.Add(length).Format("20060102150405"),
-		date.Add(length).Format("20060102150405"),
-		c.AExp,
-	)
-	ffmpeg := exec.CommandContext(
-		ctx, "ffmpeg",
-		"-headers", header,
-		"-i", input,
-		"-acodec", `copy`,
-		"-vn",
-		"-bsf:a", "aac_adtstoasc",
-		"-y", outputFile,
-	)
+	r := &errReader{r: stream}
+	errCh := make(chan error, 1)
+
+	go func() {
+		_, err := io.Copy(w, r)
+
+		w.Close()
+		errCh <- err
+	}()
+
+	waitErr := sink.Wait()
 
-	if err := ffmpeg.Run(); err != nil {
-		return fmt.Errorf("radiko: failed to complete ffmpeg command: %w", err)
+	// The sink may finish before the stream ends, e.g. when the player is closed.
+	cancel()
+	<-errCh
+
+	if err := parent.Err(); err != nil {
+		return err
+	}
+	if r.err != nil && !errors.Is(r.err, context.Canceled) {
+		return fmt.Errorf("radiko: failed to read live stream: %w", r.err)
+	}
+	if waitErr != nil {
+		return fmt.Errorf("radiko: %w", waitErr)
 	}
 
 	return nil
 }
 
+// errReader keeps the error of r other than io.EOF, to tell it from the errors of the writer.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+
+	return n, err
+}
+
+// Rec downloads the timefree stream and records it.
+//
+// The output is an m4a file, or a raw ADTS file when outputFile has '.aac' extension.
+func (c *Client) Rec(ctx context.Context, date time.Time, length time.Duration, outputFile string, options ...RecOption) error {
+	config := newRecConfig(options)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+
+	stream, err := c.openStream(ctx, func() string {
+		return c.timefreeURL(date, length)
+	})
+
+	if err != nil {
+		return err
+	}
+	if config.resume {
+		err = c.recordResumable(stream, recState{StationID: c.station, Start: date, Length: length}, outputFile, config)
+	} else {
+		err = c.record(config.reader(stream), outputFile, config.outputTags())
+	}
+	if err != nil {
+		return err
+	}
+
+	return config.writeSidecar(outputFile)
+}
+
+// RecLive records the live stream for the length.
+//
+// The output is an m4a file, or a raw ADTS file when outputFile has '.aac' extension. Only the options of the tags and the sidecar take effect.
+//
+// When ctx is canceled, the audio recorded so far is saved as a complete file and ctx.Err() is returned.
+func (c *Client) RecLive(ctx context.Context, length time.Duration, outputFile string, options ...RecOption) error {
+	config := newRecConfig(options)
+	parent := ctx
+
+	ctx, cancel := context.WithTimeout(parent, length)
+
+	defer cancel()
+
+	stream, err := c.openLiveStream(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if err := c.record(&deadlineReader{ctx: ctx, r: stream}, outputFile, config.outputTags()); err != nil {
+		return err
+	}
+	if err := config.writeSidecar(outputFile); err != nil {
+		return err
+	}
+
+	return parent.Err()
+}
+
+// deadlineReader reads r until ctx is done, either by the deadline or by the cancellation.
+type deadlineReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (d *deadlineReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+
+	// The end of the stream lets the m4a file be completed.
+	if err != nil && d.ctx.Err() != nil {
+		return n, io.EOF
+	}
+
+	return n, err
+}
+
+// record writes the ADTS audio read from r to outputFile with the tags, which may be nil.
+func (c *Client) record(r io.Reader, outputFile string, tags *Tags) error {
+	file, err := os.Create(outputFile)
+
+	if err != nil {
+		return fmt.Errorf("radiko: failed to create output file: %w", err)
+	}
+
+	defer file.Close()
+
+	// The raw ADTS audio is written as is when the output is an AAC file.
+	if strings.EqualFold(filepath.Ext(outputFile), ".aac") {
+		if tags != nil {
+			if _, err := file.Write(tags.ID3()); err != nil {
+				return fmt.Errorf("radiko: failed to write tags: %w", err)
+			}
+		}
+		if _, err := io.Copy(file, r); err != nil {
+			return fmt.Errorf("radiko: failed to record: %w", err)
+		}
+
+		return file.Close()
+	}
+
+	m4a := NewM4AWriter(file)
+
+	if tags != nil {
+		m4a.SetTags(*tags)
+	}
+
+	if _, err := io.Copy(m4a, r); err != nil {
+		return fmt.Errorf("radiko: failed to record: %w", err)
+	}
+	if err := m4a.Close(); err != nil {
+		return fmt.Errorf("radiko: failed to complete m4a file: %w", err)
+	}
+
+	c.debug.Printf("rec: recorded %s to %s\n", m4a.Duration(), outputFile)
+
+	return file.Close()
+}
+
+// RecProgram records the program with Rec method.
+//
+// The station of the client is switched to the station of the program. The tags of the program are embedded unless WithTags or WithoutTags is given.
+func (c *Client) RecProgram(ctx context.Context, program Program, outputFile string, options ...RecOption) error {
+	if program.StationID != "" {
+		c.SetStation(program.StationID)
+	}
+	if time.Now().Before(program.End) {
+		return fmt.Errorf("radiko: %q is not available on timefree until %s", program.Title, program.End.Format(time.RFC3339))
+	}
+	if config := newRecConfig(options); !config.noTags && config.tags == nil {
+		options = append(options, WithTags(c.ProgramTags(ctx, program)))
+	}
+
+	return c.Rec(ctx, program.Start, program.Duration(), outputFile, options...)
+}
+
 // SetLogger sets a logger for printing debug messages.
 func (c *Client) SetLogger(logger *log.Logger) {
 	if logger == nil {
@@ -0,0 +1,540 @@
+package radiko
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko/radikotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/area" || r.Header.Get("User-Agent") != "go-radiko-test" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		fmt.Fprint(w, `document.write('<span class="JP13">TOKYO JAPAN</span>');`)
+	}))
+
+	defer server.Close()
+
+	client := New(
+		"FMT", "", "",
+		WithBaseURL(server.URL+"/"),
+		WithHTTPClient(&http.Client{Timeout: 5 * time.Second}),
+		WithUserAgent("go-radiko-test"),
+	)
+
+	require.NoError(t, client.GetAreaName(context.Background()))
+	require.Equal(t, "JP13", client.AreaName)
+}
+
+func newTestClient(server *radikotest.Server, station, username, password string) *Client {
+	return New(station, username, password, WithBaseURL(server.URL), WithStreamBaseURL(server.URL))
+}
+
+func TestAuthenticate(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	client := newTestClient(server, "FMT", "", "")
+
+	s, err := client.Authenticate(context.Background())
+
+	require.NoError(t, err)
+	require.Equal(t, "JP13", s.AreaName)
+	require.Equal(t, MemberTypeNormal, s.MemberType)
+	require.NotEmpty(t, s.AuthToken)
+	require.False(t, s.Expired(time.Now()))
+	require.Equal(t, s, client.Session)
+}
+
+func TestAuthenticatePremium(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.Username = "you@example.com"
+	server.Password = "password"
+
+	client := newTestClient(server, "FMT", "you@example.com", "password")
+
+	s, err := client.Authenticate(context.Background())
+
+	require.NoError(t, err)
+	require.Equal(t, MemberTypePremium, s.MemberType)
+	require.Equal(t, "premium-session", s.RadikoSession)
+	require.Equal(t, 0, server.Requests(radikotest.EndpointCheck))
+
+	client = newTestClient(server, "FMT", "you@example.com", "wrong")
+
+	_, err = client.Authenticate(context.Background())
+
+	require.Error(t, err)
+}
+
+func TestAuthenticateFailure(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.Fail(radikotest.EndpointAuth1, http.StatusInternalServerError, 1)
+
+	client := newTestClient(server, "FMT", "", "")
+
+	_, err := client.Authenticate(context.Background())
+
+	require.Error(t, err)
+
+	_, err = client.Authenticate(context.Background())
+
+	require.NoError(t, err)
+}
+
+func TestSessionCache(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	cache, err := NewFileSessionCache(t.TempDir())
+
+	require.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "output.aac")
+	date := time.Now().Add(-time.Hour)
+
+	for i := 0; i < 2; i++ {
+		client := newTestClient(server, "FMT", "", "")
+		client.SetSessionCache(cache)
+
+		require.NoError(t, client.Rec(context.Background(), date, 10*time.Second, output))
+	}
+
+	require.Equal(t, 1, server.Requests(radikotest.EndpointAuth1))
+
+	// The cached session is discarded and renewed when the token is rejected.
+	server.ExpireTokens()
+
+	client := newTestClient(server, "FMT", "", "")
+	client.SetSessionCache(cache)
+
+	require.NoError(t, client.Rec(context.Background(), date, 10*time.Second, output))
+	require.Equal(t, 2, server.Requests(radikotest.EndpointAuth1))
+
+	client = newTestClient(server, "FMT", "", "")
+	client.SetSessionCache(cache)
+
+	require.NoError(t, client.Rec(context.Background(), date, 10*time.Second, output))
+	require.Equal(t, 2, server.Requests(radikotest.EndpointAuth1))
+}
+
+func TestRec(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	client := newTestClient(server, "FMT", "", "")
+	output := filepath.Join(t.TempDir(), "output.m4a")
+	date := time.Now().Add(-time.Hour).Truncate(time.Minute)
+
+	require.NoError(t, client.Rec(context.Background(), date, time.Minute, output))
+
+	data, err := os.ReadFile(output)
+
+	require.NoError(t, err)
+
+	mdhd := findBox(data, "moov", "trak", "mdia", "mdhd")
+	samples := binary.BigEndian.Uint32(mdhd[16:])
+
+	// 12 segments of 5 seconds which contain 117 frames of 1024 samples.
+	require.Equal(t, uint32(12*117*1024), samples)
+	require.Equal(t, 12, server.Requests(radikotest.EndpointSegment))
+}
+
+func TestRecParallel(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	client := newTestClient(server, "FMT", "", "")
+	dir := t.TempDir()
+	date := time.Now().Add(-time.Hour).Truncate(time.Minute)
+
+	require.NoError(t, client.Rec(context.Background(), date, time.Minute, filepath.Join(dir, "sequential.aac")))
+	require.NoError(t, client.Rec(context.Background(), date, time.Minute, filepath.Join(dir, "parallel.aac"), WithConcurrency(4)))
+
+	sequential, err := os.ReadFile(filepath.Join(dir, "sequential.aac"))
+
+	require.NoError(t, err)
+
+	parallel, err := os.ReadFile(filepath.Join(dir, "parallel.aac"))
+
+	require.NoError(t, err)
+	require.Equal(t, sequential, parallel)
+	require.Equal(t, 24, server.Requests(radikotest.EndpointSegment))
+
+	server.Fail(radikotest.EndpointSegment, http.StatusInternalServerError, 1)
+
+	err = client.Rec(context.Background(), date, time.Minute, filepath.Join(dir, "failed.aac"), WithConcurrency(4))
+
+	var statusErr *HTTPStatusError
+
+	require.True(t, errors.As(err, &statusErr))
+	require.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+}
+
+func TestParallelSessionRejected(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newTestClient(server, "FMT", "", "")
+	date := time.Now().Add(-time.Hour).Truncate(time.Minute)
+
+	stream, err := client.openStream(ctx, func() string {
+		return client.timefreeURL(date, time.Minute)
+	})
+
+	require.NoError(t, err)
+
+	rejected := client.session().AuthToken
+
+	// All workers are rejected and discard the session at the same time.
+	server.ExpireTokens()
+
+	_, err = io.ReadAll(stream.parallel(4))
+
+	require.True(t, isForbidden(err))
+	require.Nil(t, client.session())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := client.authenticate(ctx)
+
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, 2, server.Requests(radikotest.EndpointAuth1))
+
+	// The download rejected with the old token doesn't discard the new session.
+	client.invalidateSession(rejected)
+
+	require.NotNil(t, client.session())
+	require.NoError(t, client.Rec(ctx, date, time.Minute, filepath.Join(t.TempDir(), "output.aac"), WithConcurrency(4)))
+	require.Equal(t, 2, server.Requests(radikotest.EndpointAuth1))
+}
+
+func TestRecResume(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	client := newTestClient(server, "FMT", "", "")
+	dir := t.TempDir()
+	date := time.Now().Add(-time.Hour).Truncate(time.Minute)
+
+	require.NoError(t, client.Rec(context.Background(), date, time.Minute, filepath.Join(dir, "expected.aac")))
+
+	expected, err := os.ReadFile(filepath.Join(dir, "expected.aac"))
+
+	require.NoError(t, err)
+
+	// Simulate a crash after 5 segments were completed and the 6th one was partially written.
+	output := filepath.Join(dir, "output.aac")
+	first := int(date.UnixNano() / int64(5*time.Second))
+	state := recState{StationID: "FMT", Start: date, Length: time.Minute, FirstSequence: first, LastSequence: first + 4}
+
+	var part []byte
+
+	for i := 0; i < 5; i++ {
+		part = append(part, stripID3(radikotest.Segment(first+i, 5*time.Second))...)
+	}
+
+	state.Size = int64(len(part))
+
+	require.NoError(t, os.WriteFile(output+".part", append(part, 0xff, 0xf1), 0644))
+	require.NoError(t, saveRecState(output+".part.json", state))
+
+	requests := server.Requests(radikotest.EndpointSegment)
+
+	require.NoError(t, client.Rec(context.Background(), date, time.Minute, output, WithResume(), WithConcurrency(2)))
+	require.Equal(t, 7, server.Requests(radikotest.EndpointSegment)-requests)
+
+	actual, err := os.ReadFile(output)
+
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	_, err = os.Stat(output + ".part")
+
+	require.True(t, errors.Is(err, os.ErrNotExist))
+
+	_, err = os.Stat(output + ".part.json")
+
+	require.True(t, errors.Is(err, os.ErrNotExist))
+
+	// A failed recording keeps the part file to be resumed.
+	output = filepath.Join(dir, "output.m4a")
+
+	server.Fail(radikotest.EndpointSegment, http.StatusInternalServerError, 0)
+
+	require.Error(t, client.Rec(context.Background(), date, time.Minute, output, WithResume()))
+
+	_, err = os.Stat(output + ".part")
+
+	require.NoError(t, err)
+
+	server.Recover(radikotest.EndpointSegment)
+
+	require.NoError(t, client.Rec(context.Background(), date, time.Minute, output, WithResume()))
+
+	data, err := os.ReadFile(output)
+
+	require.NoError(t, err)
+
+	mdhd := findBox(data, "moov", "trak", "mdia", "mdhd")
+
+	require.Equal(t, uint32(12*117*1024), binary.BigEndian.Uint32(mdhd[16:]))
+}
+
+func TestGetPrograms(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	client := newTestClient(server, "FMT", "", "")
+
+	require.NoError(t, client.GetPrograms(context.Background(), time.Date(2023, 10, 17, 0, 0, 0, 0, JST)))
+	require.Len(t, client.Programs, 24)
+	require.Equal(t, time.Date(2023, 10, 17, 5, 0, 0, 0, JST), client.Programs[0].Start)
+
+	require.NoError(t, client.GetWeeklyPrograms(context.Background()))
+	require.Len(t, client.Programs, 14*24)
+
+	today := BroadcastDate(time.Now())
+
+	require.NoError(t, client.GetGuide(context.Background(), today, true))
+	require.Len(t, client.Programs, 8*24)
+	require.Equal(t, today.Add(5*time.Hour), client.Programs[0].Start)
+
+	require.NoError(t, client.GetGuide(context.Background(), today, false))
+	require.Len(t, client.Programs, 24)
+
+	require.NoError(t, client.GetNowOnAir(context.Background()))
+	require.True(t, client.NowOnAir.Start.Before(time.Now()))
+	require.True(t, client.NowOnAir.End.After(time.Now()))
+
+	require.NoError(t, client.GetOnAirSongs(context.Background()))
+	require.Len(t, client.OnAirSongs, 1)
+}
+
+func TestGetAllStations(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	client := newTestClient(server, "", "", "")
+
+	require.NoError(t, client.GetAllStations(context.Background()))
+	require.True(t, StationSlice(client.AllStations).Match(func(s Station) bool {
+		return s.ID == "FMT"
+	}))
+
+	logo, err := client.GetStationLogo(context.Background(), client.AllStations[0], 224, 100)
+
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(logo, []byte("\x89PNG")))
+
+	server.Fail(radikotest.EndpointImage, http.StatusNotFound, 1)
+
+	_, err = client.GetStationLogo(context.Background(), client.AllStations[0], 224, 100)
+
+	var statusErr *HTTPStatusError
+
+	require.True(t, errors.As(err, &statusErr))
+	require.Equal(t, "logo", statusErr.Step)
+}
+
+func TestCheckStation(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.Username = "you@example.com"
+	server.Password = "secret"
+
+	client := newTestClient(server, "", "", "")
+
+	a, err := client.CheckStation(context.Background(), "FMT")
+
+	require.NoError(t, err)
+	require.Equal(t, Availability{StationID: "FMT", InArea: true, Live: true, Timefree: true}, a)
+	require.Equal(t, "JP13", client.AreaName)
+
+	a, err = client.CheckStation(context.Background(), "ABC")
+
+	require.NoError(t, err)
+	require.Equal(t, Availability{StationID: "ABC"}, a)
+
+	_, err = client.CheckStation(context.Background(), "UNKNOWN")
+
+	require.True(t, errors.Is(err, ErrStationNotFound))
+
+	premium := newTestClient(server, "", "you@example.com", "secret")
+
+	a, err = premium.CheckStation(context.Background(), "ABC")
+
+	require.NoError(t, err)
+	require.Equal(t, Availability{StationID: "ABC", Live: true, Timefree: true}, a)
+
+	a, err = premium.CheckStation(context.Background(), "JOBK")
+
+	require.NoError(t, err)
+	require.Equal(t, Availability{StationID: "JOBK"}, a)
+	require.Equal(t, 2, server.Requests(radikotest.EndpointAreaStations))
+	require.Equal(t, 2, server.Requests(radikotest.EndpointStations))
+}
+
+func TestErrors(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.AreaID = "OUT"
+
+	_, err := newTestClient(server, "FMT", "", "").Authenticate(context.Background())
+
+	require.True(t, errors.Is(err, ErrGeoRestricted))
+
+	server.AreaID = "JP13"
+
+	_, err = newTestClient(server, "FMT", "you@example.com", "wrong").Authenticate(context.Background())
+
+	var statusErr *HTTPStatusError
+
+	require.True(t, errors.Is(err, ErrLoginFailed))
+	require.True(t, errors.As(err, &statusErr))
+	require.Equal(t, "login", statusErr.Step)
+	require.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+
+	server.Fail(radikotest.EndpointTimefree, http.StatusForbidden, 0)
+
+	err = newTestClient(server, "FMT", "", "").Rec(context.Background(), time.Now().Add(-time.Hour), time.Minute, filepath.Join(t.TempDir(), "output.m4a"))
+
+	require.True(t, errors.Is(err, ErrStationNotInArea))
+}
+
+func TestRecLive(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.SegmentDuration = time.Second
+
+	client := newTestClient(server, "FMT", "", "")
+	output := filepath.Join(t.TempDir(), "output.m4a")
+
+	require.NoError(t, client.RecLive(context.Background(), 2500*time.Millisecond, output))
+
+	data, err := os.ReadFile(output)
+
+	require.NoError(t, err)
+	require.NotNil(t, findBox(data, "moov"))
+	require.True(t, server.Requests(radikotest.EndpointSegment) >= 3)
+
+	// The recording stopped by the cancellation is completed as well.
+	ctx, cancel := context.WithCancel(context.Background())
+
+	time.AfterFunc(1500*time.Millisecond, cancel)
+
+	err = client.RecLive(ctx, time.Minute, output)
+
+	require.True(t, errors.Is(err, context.Canceled))
+
+	data, err = os.ReadFile(output)
+
+	require.NoError(t, err)
+	require.NotNil(t, findBox(data, "moov"))
+}
+
+func TestLiveStreamReconnect(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.SegmentDuration = time.Second
+
+	client := New("FMT", "", "", WithBaseURL(server.URL), WithStreamBaseURL(server.URL), WithReconnectPolicy(ReconnectPolicy{
+		MaxAttempts:  3,
+		Delay:        10 * time.Millisecond,
+		StallTimeout: time.Second,
+	}))
+
+	ctx := context.Background()
+	stream, err := client.openLiveStream(ctx)
+
+	require.NoError(t, err)
+
+	_, first, err := stream.Next()
+
+	require.NoError(t, err)
+
+	// The expired token is issued again and the stream continues from the next segment.
+	server.ExpireTokens()
+
+	_, second, err := stream.Next()
+
+	require.NoError(t, err)
+	require.Equal(t, first.Sequence+1, second.Sequence)
+	require.Equal(t, 2, server.Requests(radikotest.EndpointAuth1))
+
+	server.Fail(radikotest.EndpointSegment, http.StatusBadGateway, 2)
+
+	_, third, err := stream.Next()
+
+	require.NoError(t, err)
+	require.Equal(t, second.Sequence+1, third.Sequence)
+
+	server.Fail(radikotest.EndpointSegment, http.StatusBadGateway, 0)
+
+	_, _, err = stream.Next()
+
+	var statusErr *HTTPStatusError
+
+	require.True(t, errors.As(err, &statusErr))
+	require.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+}
+
+func TestReconnectPolicyDelay(t *testing.T) {
+	policy := ReconnectPolicy{Delay: time.Second, MaxDelay: 5 * time.Second}
+
+	require.Equal(t, time.Second, policy.delay(0))
+	require.Equal(t, 4*time.Second, policy.delay(2))
+	require.Equal(t, 5*time.Second, policy.delay(10))
+}
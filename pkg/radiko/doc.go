@@ -1,6 +1,6 @@
 /*
 Package radiko provides utility functions such as authentication, lists available radio stations, and so on.
 
-This package doesn't provide features related playing or recording audio stream.
+Stream reads the live and timefree HLS streams as ADTS audio and M4AWriter converts it into an m4a file, so recording doesn't require any external command. PlayTo writes the live stream to an AudioSink, such as ffplay or mpv command, a file, a named pipe or any io.Writer of your program.
 */
 package radiko
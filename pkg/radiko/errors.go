@@ -0,0 +1,39 @@
+package radiko
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrGeoRestricted is returned when radiko.jp is not available from your IP.
+	ErrGeoRestricted = errors.New("your IP is geo-restricted")
+
+	// ErrLoginFailed is returned when the premium member login is rejected.
+	ErrLoginFailed = errors.New("failed to login")
+
+	// ErrAuthFailed is returned when the authentication steps are not completed.
+	ErrAuthFailed = errors.New("failed to authenticate")
+
+	// ErrStationNotInArea is returned when the station is not available in your area.
+	ErrStationNotInArea = errors.New("station is not available in your area")
+
+	// ErrStationNotFound is returned when no station has the ID.
+	ErrStationNotFound = errors.New("station not found")
+
+	// ErrProgramNotFound is returned when no program matches.
+	ErrProgramNotFound = errors.New("program not found")
+)
+
+// HTTPStatusError is returned when radiko.jp responds with an unexpected status code.
+type HTTPStatusError struct {
+	// Step is the name of the step such as "auth1" or "stream".
+	Step string
+
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %s", e.Status)
+}
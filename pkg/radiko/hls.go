@@ -0,0 +1,521 @@
+package radiko
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// M3U8 represents an HLS playlist.
+//
+// A master playlist has Variants and a media playlist has Segments.
+type M3U8 struct {
+	Variants       []string
+	TargetDuration time.Duration
+	MediaSequence  int
+	Segments       []Segment
+	EndList        bool
+}
+
+// Segment represents a media segment in an HLS playlist.
+type Segment struct {
+	Sequence int
+	Duration time.Duration
+	URI      string
+}
+
+// ParseM3U8 parses an HLS playlist. Relative URIs are resolved against base.
+func ParseM3U8(r io.Reader, base *url.URL) (*M3U8, error) {
+	scanner := bufio.NewScanner(r)
+
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "#EXTM3U" {
+		return nil, fmt.Errorf("radiko: failed to parse M3U8: missing #EXTM3U")
+	}
+
+	v := &M3U8{}
+
+	var (
+		isVariant bool
+		duration  time.Duration
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			isVariant = true
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"))
+
+			if err != nil {
+				return nil, fmt.Errorf("radiko: failed to parse target duration: %w", err)
+			}
+
+			v.TargetDuration = time.Duration(seconds) * time.Second
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			sequence, err := strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+
+			if err != nil {
+				return nil, fmt.Errorf("radiko: failed to parse media sequence: %w", err)
+			}
+
+			v.MediaSequence = sequence
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value := strings.TrimPrefix(line, "#EXTINF:")
+
+			if i := strings.Index(value, ","); i >= 0 {
+				value = value[:i]
+			}
+
+			seconds, err := strconv.ParseFloat(value, 64)
+
+			if err != nil {
+				return nil, fmt.Errorf("radiko: failed to parse segment duration: %w", err)
+			}
+
+			duration = time.Duration(seconds * float64(time.Second))
+		case line == "#EXT-X-ENDLIST":
+			v.EndList = true
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			uri, err := resolveURI(base, line)
+
+			if err != nil {
+				return nil, err
+			}
+			if isVariant {
+				v.Variants = append(v.Variants, uri)
+				isVariant = false
+
+				continue
+			}
+
+			v.Segments = append(v.Segments, Segment{
+				Sequence: v.MediaSequence + len(v.Segments),
+				Duration: duration,
+				URI:      uri,
+			})
+
+			duration = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("radiko: failed to read M3U8: %w", err)
+	}
+
+	return v, nil
+}
+
+func resolveURI(base *url.URL, s string) (string, error) {
+	u, err := url.Parse(s)
+
+	if err != nil {
+		return "", fmt.Errorf("radiko: failed to parse URI: %w", err)
+	}
+	if base == nil {
+		return u.String(), nil
+	}
+
+	return base.ResolveReference(u).String(), nil
+}
+
+// stripID3 removes ID3v2 tags prepended to an AAC segment.
+func stripID3(data []byte) []byte {
+	for len(data) >= 10 && string(data[:3]) == "ID3" {
+		size := int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f)
+		size += 10
+
+		// The footer is present.
+		if data[5]&0x10 != 0 {
+			size += 10
+		}
+		if size > len(data) {
+			return nil
+		}
+
+		data = data[size:]
+	}
+
+	return data
+}
+
+// Stream reads ADTS audio from an HLS stream of radiko.jp.
+//
+// A live stream never ends. A timefree stream returns io.EOF after the last segment.
+type Stream struct {
+	ctx    context.Context
+	client *Client
+	url    string
+
+	mediaURL       string
+	targetDuration time.Duration
+	sequence       int
+	segments       []Segment
+	endList        bool
+	buf            []byte
+	reconnect      ReconnectPolicy
+}
+
+// LiveStream returns a stream of the live broadcast of the station. It reconnects according to the reconnect policy of the client.
+//
+// You need to complete authentication before reading the stream.
+func (c *Client) LiveStream(ctx context.Context) *Stream {
+	stream := c.NewStream(ctx, c.liveURL())
+	stream.reconnect = c.reconnect
+
+	return stream
+}
+
+// OpenLiveStream authenticates unless a valid session is available, and opens the live stream of the station.
+//
+// Unlike LiveStream, the playlist is loaded before returning, so that the errors such as ErrStationNotInArea are reported immediately.
+func (c *Client) OpenLiveStream(ctx context.Context) (*Stream, error) {
+	return c.openLiveStream(ctx)
+}
+
+// TimefreeStream returns a stream of the broadcast in the past.
+//
+// You need to complete authentication before reading the stream.
+func (c *Client) TimefreeStream(ctx context.Context, date time.Time, length time.Duration) *Stream {
+	return c.NewStream(ctx, c.timefreeURL(date, length))
+}
+
+// NewStream returns a stream which reads the HLS playlist located at u.
+func (c *Client) NewStream(ctx context.Context, u string) *Stream {
+	return &Stream{
+		ctx:      ctx,
+		client:   c,
+		url:      u,
+		sequence: -1,
+	}
+}
+
+func (c *Client) liveURL() string {
+	return fmt.Sprintf(
+		"%s/so/playlist.m3u8?station_id=%s&l=15&lsid=%s&type=c",
+		c.streamBaseURL,
+		c.station,
+		c.AExp,
+	)
+}
+
+func (c *Client) timefreeURL(date time.Time, length time.Duration) string {
+	start := date.In(JST).Format("20060102150405")
+	end := date.Add(length).In(JST).Format("20060102150405")
+
+	return fmt.Sprintf(
+		"%s/tf/playlist.m3u8?station_id=%s&start_at=%s&ft=%s&end_at=%s&to=%s&l=15&lsid=%s&type=c",
+		c.streamBaseURL,
+		c.station,
+		start,
+		start,
+		end,
+		end,
+		c.AExp,
+	)
+}
+
+// Read reads ADTS audio.
+func (s *Stream) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		data, _, err := s.Next()
+
+		if err != nil {
+			return 0, err
+		}
+
+		s.buf = data
+	}
+
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+
+	return n, nil
+}
+
+func (s *Stream) next() ([]byte, Segment, error) {
+	for reloaded := false; len(s.segments) == 0; reloaded = true {
+		if s.endList {
+			return nil, Segment{}, io.EOF
+		}
+		if reloaded {
+			if err := s.wait(); err != nil {
+				return nil, Segment{}, err
+			}
+		}
+		if err := s.reload(); err != nil {
+			return nil, Segment{}, err
+		}
+	}
+
+	segment := s.segments[0]
+
+	ctx, cancel := s.requestContext()
+
+	defer cancel()
+
+	data, err := s.client.fetchStream(ctx, "segment", segment.URI)
+
+	if err != nil {
+		return nil, Segment{}, err
+	}
+
+	s.segments = s.segments[1:]
+	s.sequence = segment.Sequence + 1
+
+	return stripID3(data), segment, nil
+}
+
+func (s *Stream) wait() error {
+	d := s.targetDuration / 2
+
+	if d < time.Second {
+		d = time.Second
+	}
+
+	return s.sleep(d)
+}
+
+func (s *Stream) sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+
+	defer timer.Stop()
+
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// requestContext returns the context of a request, which is aborted when the request stalls.
+func (s *Stream) requestContext() (context.Context, context.CancelFunc) {
+	if s.reconnect.StallTimeout > 0 {
+		return context.WithTimeout(s.ctx, s.reconnect.StallTimeout)
+	}
+
+	return context.WithCancel(s.ctx)
+}
+
+func (s *Stream) reload() error {
+	ctx, cancel := s.requestContext()
+
+	defer cancel()
+
+	if s.mediaURL == "" {
+		playlist, err := s.client.fetchM3U8(ctx, s.url)
+
+		if err != nil {
+			return err
+		}
+		if len(playlist.Variants) == 0 {
+			s.mediaURL = s.url
+		} else {
+			s.mediaURL = playlist.Variants[0]
+		}
+	}
+
+	playlist, err := s.client.fetchM3U8(ctx, s.mediaURL)
+
+	if err != nil {
+		return err
+	}
+
+	s.targetDuration = playlist.TargetDuration
+	s.endList = playlist.EndList
+
+	for _, segment := range playlist.Segments {
+		if segment.Sequence >= s.sequence {
+			s.segments = append(s.segments, segment)
+		}
+	}
+	if len(s.segments) > 0 && s.sequence >= 0 && s.segments[0].Sequence > s.sequence {
+		s.client.debug.Printf("stream: missed %d segments\n", s.segments[0].Sequence-s.sequence)
+	}
+
+	s.client.debug.Printf("stream: loaded playlist: sequence=%d, segments=%d, endlist=%v\n", playlist.MediaSequence, len(playlist.Segments), playlist.EndList)
+
+	return nil
+}
+
+// segmentReader reads the segments of a stream in order.
+type segmentReader interface {
+	io.Reader
+	Next() ([]byte, Segment, error)
+}
+
+// skip drops the loaded segments up to the sequence.
+func (s *Stream) skip(sequence int) {
+	var segments []Segment
+
+	for _, segment := range s.segments {
+		if segment.Sequence > sequence {
+			segments = append(segments, segment)
+		}
+	}
+
+	s.segments = segments
+
+	if s.sequence <= sequence {
+		s.sequence = sequence + 1
+	}
+}
+
+// parallel returns a reader which downloads the rest of the segments with n workers and reads them in order.
+//
+// The playlist must be loaded and finished, otherwise the stream itself is returned. Cancel the context of the stream to stop the workers.
+func (s *Stream) parallel(n int) segmentReader {
+	if !s.endList {
+		return s
+	}
+
+	segments := s.segments
+	s.segments = nil
+
+	s.client.debug.Printf("stream: downloading %d segments with %d workers\n", len(segments), n)
+
+	// Each download is queued in order as a channel which receives its result. The capacity of the queue bounds the number of workers.
+	results := make(chan chan segmentResult, n-1)
+
+	go func() {
+		defer close(results)
+
+		for _, segment := range segments {
+			result := make(chan segmentResult, 1)
+
+			select {
+			case <-s.ctx.Done():
+				return
+			case results <- result:
+			}
+
+			go func(segment Segment) {
+				data, err := s.client.fetchStream(s.ctx, "segment", segment.URI)
+
+				result <- segmentResult{data: stripID3(data), segment: segment, err: err}
+			}(segment)
+		}
+	}()
+
+	return &parallelReader{ctx: s.ctx, results: results}
+}
+
+type segmentResult struct {
+	data    []byte
+	segment Segment
+	err     error
+}
+
+type parallelReader struct {
+	ctx     context.Context
+	results chan chan segmentResult
+	buf     []byte
+	err     error
+}
+
+// Next returns the ADTS audio of the next segment.
+func (p *parallelReader) Next() ([]byte, Segment, error) {
+	result, ok := <-p.results
+
+	if !ok {
+		if err := p.ctx.Err(); err != nil {
+			return nil, Segment{}, err
+		}
+
+		return nil, Segment{}, io.EOF
+	}
+
+	r := <-result
+
+	if r.err != nil {
+		return nil, Segment{}, r.err
+	}
+
+	return r.data, r.segment, nil
+}
+
+func (p *parallelReader) Read(b []byte) (int, error) {
+	for len(p.buf) == 0 {
+		if p.err != nil {
+			return 0, p.err
+		}
+
+		p.buf, _, p.err = p.Next()
+	}
+
+	n := copy(b, p.buf)
+	p.buf = p.buf[n:]
+
+	return n, nil
+}
+
+func (c *Client) fetchM3U8(ctx context.Context, u string) (*M3U8, error) {
+	data, err := c.fetchStream(ctx, "playlist.m3u8", u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(u)
+
+	if err != nil {
+		return nil, fmt.Errorf("stream: failed to parse URL: %w", err)
+	}
+
+	playlist, err := ParseM3U8(bytes.NewReader(data), base)
+
+	if err != nil {
+		return nil, fmt.Errorf("stream: failed to parse %s: %w", u, err)
+	}
+
+	return playlist, nil
+}
+
+func (c *Client) fetchStream(ctx context.Context, step, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("stream: failed to create request: %w", err)
+	}
+
+	token := c.authToken()
+
+	req.Header.Set("X-Radiko-AuthToken", token)
+
+	res, err := c.do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("stream: error response: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	c.debug.Printf("stream: %s: status code: %s\n", step, res.Status)
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		c.invalidateSession(token)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stream: %s: %w", step, &HTTPStatusError{Step: "stream", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
+	data, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("stream: failed to read response body: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,97 @@
+package radiko
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseM3U8(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "Master.m3u8"))
+
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	master, err := ParseM3U8(file, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com/v2/api/ts/chunklist/NejwVmXN.m3u8"}, master.Variants)
+	require.Empty(t, master.Segments)
+
+	file, err = os.Open(filepath.Join("testdata", "Media.m3u8"))
+
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	base, err := url.Parse("https://example.com/sound/b/FMT/20231017/chunklist.m3u8")
+
+	require.NoError(t, err)
+
+	media, err := ParseM3U8(file, base)
+
+	require.NoError(t, err)
+	require.Empty(t, media.Variants)
+	require.True(t, media.EndList)
+	require.Equal(t, 5*time.Second, media.TargetDuration)
+	require.Len(t, media.Segments, 3)
+	require.Equal(t, 42, media.Segments[0].Sequence)
+	require.Equal(t, 44, media.Segments[2].Sequence)
+	require.Equal(t, 4500*time.Millisecond, media.Segments[2].Duration)
+	require.Equal(t, "https://example.com/sound/b/FMT/20231017/20231017_120010_KlMnO.aac", media.Segments[2].URI)
+}
+
+func TestStripID3(t *testing.T) {
+	tag := []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 3, 1, 2, 3}
+	audio := []byte{0xff, 0xf1, 0x50, 0x80}
+
+	require.Equal(t, audio, stripID3(append(tag, audio...)))
+	require.Equal(t, audio, stripID3(audio))
+}
+
+func TestStream(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/master.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=52973\nmedia.m3u8\n")
+	})
+	mux.HandleFunc("/media.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "#EXTM3U\n#EXT-X-TARGETDURATION:5\n#EXT-X-MEDIA-SEQUENCE:1\n#EXTINF:5,\n1.aac\n#EXTINF:5,\n2.aac\n#EXT-X-ENDLIST\n")
+	})
+	mux.HandleFunc("/1.aac", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 1, 0, 'a'})
+	})
+	mux.HandleFunc("/2.aac", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte{'b'})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Radiko-AuthToken") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	}))
+
+	defer server.Close()
+
+	client := New("FMT", "", "")
+	client.AuthToken = "token"
+
+	data, err := io.ReadAll(client.NewStream(context.Background(), server.URL+"/master.m3u8"))
+
+	require.NoError(t, err)
+	require.Equal(t, "ab", string(data))
+}
@@ -0,0 +1,306 @@
+package radiko
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// samplesPerFrame is the number of PCM samples in an AAC frame.
+const samplesPerFrame = 1024
+
+// M4AWriter converts ADTS audio into a progressive MP4 (m4a) file.
+//
+// The data written is buffered until a whole ADTS frame is available. Close must be called to complete the file.
+type M4AWriter struct {
+	w io.WriteSeeker
+
+	header     ADTSHeader
+	tags       *Tags
+	buf        []byte
+	sizes      []uint32
+	offset     int64
+	mdatOffset int64
+	mdatSize   int64
+	started    bool
+	closed     bool
+}
+
+// NewM4AWriter returns a writer which writes m4a file to w.
+func NewM4AWriter(w io.WriteSeeker) *M4AWriter {
+	return &M4AWriter{w: w}
+}
+
+// SetTags sets the metadata written when closing.
+func (m *M4AWriter) SetTags(tags Tags) {
+	m.tags = &tags
+}
+
+// Write writes ADTS audio.
+func (m *M4AWriter) Write(p []byte) (int, error) {
+	if m.closed {
+		return 0, fmt.Errorf("radiko: write to closed M4AWriter")
+	}
+
+	m.buf = append(m.buf, p...)
+
+	for len(m.buf) >= 7 {
+		h, err := ParseADTSHeader(m.buf)
+
+		if err != nil {
+			// Skip a byte to find the next sync word.
+			m.buf = m.buf[1:]
+
+			continue
+		}
+		if len(m.buf) < h.FrameLength {
+			break
+		}
+		if err := m.writeFrame(h, m.buf[h.HeaderLength:h.FrameLength]); err != nil {
+			return 0, err
+		}
+
+		m.buf = m.buf[h.FrameLength:]
+	}
+
+	return len(p), nil
+}
+
+func (m *M4AWriter) writeFrame(h ADTSHeader, payload []byte) error {
+	if !m.started {
+		offset, err := m.w.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return fmt.Errorf("radiko: failed to get offset: %w", err)
+		}
+
+		m.offset = offset
+		m.header = h
+		m.started = true
+
+		if err := m.write(mp4Box("ftyp", []byte("M4A "), u32(0x200), []byte("M4A mp42isom"))); err != nil {
+			return err
+		}
+
+		m.mdatOffset = m.offset
+
+		// The size of mdat box is updated when closing.
+		if err := m.write(mp4Box("mdat")); err != nil {
+			return err
+		}
+
+		m.mdatSize = 8
+	}
+	if h.SamplingFrequencyIndex != m.header.SamplingFrequencyIndex || h.ChannelConfiguration != m.header.ChannelConfiguration {
+		return fmt.Errorf("radiko: audio format changed in the middle of the stream")
+	}
+	if err := m.write(payload); err != nil {
+		return err
+	}
+
+	m.sizes = append(m.sizes, uint32(len(payload)))
+	m.mdatSize += int64(len(payload))
+
+	return nil
+}
+
+func (m *M4AWriter) write(p []byte) error {
+	n, err := m.w.Write(p)
+
+	m.offset += int64(n)
+
+	if err != nil {
+		return fmt.Errorf("radiko: failed to write: %w", err)
+	}
+
+	return nil
+}
+
+// Duration returns the duration of the audio written so far.
+func (m *M4AWriter) Duration() time.Duration {
+	if !m.started {
+		return 0
+	}
+
+	samples := int64(len(m.sizes)) * samplesPerFrame
+
+	return time.Duration(samples) * time.Second / time.Duration(m.header.SampleRate())
+}
+
+// Close writes the sample tables and completes the file. It doesn't close the underlying writer.
+func (m *M4AWriter) Close() error {
+	if m.closed {
+		return nil
+	}
+
+	m.closed = true
+
+	if !m.started {
+		return fmt.Errorf("radiko: no ADTS frame written")
+	}
+	if m.mdatSize > math.MaxUint32 {
+		return fmt.Errorf("radiko: audio data is too large")
+	}
+	if err := m.write(m.moov()); err != nil {
+		return err
+	}
+
+	end := m.offset
+
+	if _, err := m.w.Seek(m.mdatOffset, io.SeekStart); err != nil {
+		return fmt.Errorf("radiko: failed to seek: %w", err)
+	}
+	if _, err := m.w.Write(u32(uint32(m.mdatSize))); err != nil {
+		return fmt.Errorf("radiko: failed to write: %w", err)
+	}
+	if _, err := m.w.Seek(end, io.SeekStart); err != nil {
+		return fmt.Errorf("radiko: failed to seek: %w", err)
+	}
+
+	return nil
+}
+
+func (m *M4AWriter) moov() []byte {
+	now := mp4Time(time.Now())
+	sampleRate := uint32(m.header.SampleRate())
+	samples := uint32(len(m.sizes)) * samplesPerFrame
+	duration := uint32(uint64(samples) * 1000 / uint64(sampleRate))
+	matrix := []byte{
+		0x00, 0x01, 0x00, 0x00, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0x00, 0x01, 0x00, 0x00, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0x40, 0x00, 0x00, 0x00,
+	}
+
+	mvhd := mp4FullBox(
+		"mvhd", 0, 0,
+		u32(now), u32(now), u32(1000), u32(duration),
+		u32(0x00010000), u16(0x0100), make([]byte, 10),
+		matrix, make([]byte, 24), u32(2),
+	)
+	tkhd := mp4FullBox(
+		"tkhd", 0, 0x000003,
+		u32(now), u32(now), u32(1), u32(0), u32(duration),
+		make([]byte, 8), u16(0), u16(0), u16(0x0100), u16(0),
+		matrix, u32(0), u32(0),
+	)
+	mdhd := mp4FullBox(
+		"mdhd", 0, 0,
+		u32(now), u32(now), u32(sampleRate), u32(samples),
+		// The language code 'und' packed in ISO-639-2/T.
+		u16(0x55c4), u16(0),
+	)
+	hdlr := mp4FullBox(
+		"hdlr", 0, 0,
+		u32(0), []byte("soun"), make([]byte, 12), []byte("SoundHandler\x00"),
+	)
+	smhd := mp4FullBox("smhd", 0, 0, u16(0), u16(0))
+	dinf := mp4Box("dinf", mp4FullBox("dref", 0, 0, u32(1), mp4FullBox("url ", 0, 1)))
+
+	stsz := []byte{}
+
+	for _, size := range m.sizes {
+		stsz = append(stsz, u32(size)...)
+	}
+
+	stbl := mp4Box(
+		"stbl",
+		mp4FullBox("stsd", 0, 0, u32(1), m.mp4a()),
+		mp4FullBox("stts", 0, 0, u32(1), u32(uint32(len(m.sizes))), u32(samplesPerFrame)),
+		mp4FullBox("stsc", 0, 0, u32(1), u32(1), u32(uint32(len(m.sizes))), u32(1)),
+		mp4FullBox("stsz", 0, 0, u32(0), u32(uint32(len(m.sizes))), stsz),
+		mp4FullBox("stco", 0, 0, u32(1), u32(uint32(m.mdatOffset+8))),
+	)
+
+	trak := mp4Box(
+		"trak",
+		tkhd,
+		mp4Box("mdia", mdhd, hdlr, mp4Box("minf", smhd, dinf, stbl)),
+	)
+
+	if m.tags != nil {
+		return mp4Box("moov", mvhd, trak, m.tags.udta())
+	}
+
+	return mp4Box("moov", mvhd, trak)
+}
+
+func (m *M4AWriter) mp4a() []byte {
+	config := m.header.AudioSpecificConfig()
+
+	decoderSpecificInfo := mp4Descriptor(0x05, config)
+	decoderConfig := mp4Descriptor(
+		0x04,
+		// MPEG-4 audio, audio stream, buffer size, max bitrate and average bitrate.
+		[]byte{0x40, 0x15, 0, 0, 0}, u32(0), u32(0),
+		decoderSpecificInfo,
+	)
+	esDescriptor := mp4Descriptor(
+		0x03,
+		u16(0), []byte{0},
+		decoderConfig,
+		mp4Descriptor(0x06, []byte{0x02}),
+	)
+
+	return mp4Box(
+		"mp4a",
+		make([]byte, 6), u16(1), make([]byte, 8),
+		u16(uint16(m.header.ChannelConfiguration)), u16(16), u16(0), u16(0),
+		u32(uint32(m.header.SampleRate())<<16),
+		mp4FullBox("esds", 0, 0, esDescriptor),
+	)
+}
+
+func mp4Box(typ string, payloads ...[]byte) []byte {
+	size := 8
+
+	for _, payload := range payloads {
+		size += len(payload)
+	}
+
+	b := make([]byte, 0, size)
+	b = append(b, u32(uint32(size))...)
+	b = append(b, typ...)
+
+	for _, payload := range payloads {
+		b = append(b, payload...)
+	}
+
+	return b
+}
+
+func mp4FullBox(typ string, version byte, flags uint32, payloads ...[]byte) []byte {
+	header := u32(uint32(version)<<24 | flags&0xffffff)
+
+	return mp4Box(typ, append([][]byte{header}, payloads...)...)
+}
+
+func mp4Descriptor(tag byte, payloads ...[]byte) []byte {
+	size := 0
+
+	for _, payload := range payloads {
+		size += len(payload)
+	}
+
+	b := []byte{tag, byte(size)}
+
+	for _, payload := range payloads {
+		b = append(b, payload...)
+	}
+
+	return b
+}
+
+// mp4Time returns seconds since 1904-01-01 in UTC.
+func mp4Time(t time.Time) uint32 {
+	return uint32(t.Unix() + 2082844800)
+}
+
+func u16(v uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, v)
+}
+
+func u32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
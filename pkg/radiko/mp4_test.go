@@ -0,0 +1,105 @@
+package radiko
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// findBox returns the payload of the box located at path such as "moov/trak/mdia/mdhd".
+func findBox(data []byte, path ...string) []byte {
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data))
+
+		if size < 8 || size > len(data) {
+			return nil
+		}
+		if string(data[4:8]) == path[0] {
+			if len(path) == 1 {
+				return data[8:size]
+			}
+
+			return findBox(data[8:size], path[1:]...)
+		}
+
+		data = data[size:]
+	}
+
+	return nil
+}
+
+func TestM4AWriter(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "Sample.aac"))
+
+	require.NoError(t, err)
+
+	output, err := os.Create(filepath.Join(t.TempDir(), "output.m4a"))
+
+	require.NoError(t, err)
+
+	defer output.Close()
+
+	w := NewM4AWriter(output)
+
+	// Split the input at odd positions to test buffering of partial frames.
+	for len(input) > 0 {
+		n := 777
+
+		if n > len(input) {
+			n = len(input)
+		}
+
+		_, err := w.Write(input[:n])
+
+		require.NoError(t, err)
+
+		input = input[n:]
+	}
+
+	require.NoError(t, w.Close())
+	require.Equal(t, 43*1024*time.Second/24000, w.Duration())
+
+	data, err := os.ReadFile(output.Name())
+
+	require.NoError(t, err)
+
+	require.Equal(t, "M4A ", string(findBox(data, "ftyp")[:4]))
+
+	mdat := findBox(data, "mdat")
+
+	require.NotNil(t, mdat)
+
+	stsz := findBox(data, "moov", "trak", "mdia", "minf", "stbl", "stsz")
+
+	require.Equal(t, uint32(43), binary.BigEndian.Uint32(stsz[8:]))
+
+	total := 0
+
+	for i := 0; i < 43; i++ {
+		total += int(binary.BigEndian.Uint32(stsz[12+4*i:]))
+	}
+
+	require.Equal(t, len(mdat), total)
+
+	mdhd := findBox(data, "moov", "trak", "mdia", "mdhd")
+
+	require.Equal(t, uint32(24000), binary.BigEndian.Uint32(mdhd[12:]))
+	require.Equal(t, uint32(43*1024), binary.BigEndian.Uint32(mdhd[16:]))
+
+	stco := findBox(data, "moov", "trak", "mdia", "minf", "stbl", "stco")
+	offset := int(binary.BigEndian.Uint32(stco[8:]))
+	sample, err := os.ReadFile(filepath.Join("testdata", "Sample.aac"))
+
+	require.NoError(t, err)
+
+	// The first sample is the payload of the first ADTS frame.
+	require.Equal(t, sample[7:7+binary.BigEndian.Uint32(stsz[12:])], data[offset:offset+int(binary.BigEndian.Uint32(stsz[12:]))])
+
+	esds := findBox(data, "moov", "trak", "mdia", "minf", "stbl", "stsd")
+
+	require.Contains(t, string(esds), string([]byte{0x05, 0x02, 0x13, 0x10}))
+}
@@ -0,0 +1,137 @@
+package podcast
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+)
+
+// duration returns the duration of the m4a or ADTS file.
+func duration(path string) (time.Duration, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".aac") {
+		return adtsDuration(file)
+	}
+
+	return m4aDuration(file)
+}
+
+// m4aDuration reads the duration in the 'mvhd' box.
+func m4aDuration(r io.ReadSeeker) (time.Duration, error) {
+	header := make([]byte, 8)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, fmt.Errorf("podcast: moov box not found: %w", err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header))
+
+		if size < 8 {
+			return 0, fmt.Errorf("podcast: unsupported box size: %d", size)
+		}
+		if string(header[4:]) != "moov" {
+			if _, err := r.Seek(size-8, io.SeekCurrent); err != nil {
+				return 0, err
+			}
+
+			continue
+		}
+
+		moov := make([]byte, size-8)
+
+		if _, err := io.ReadFull(r, moov); err != nil {
+			return 0, err
+		}
+
+		return mvhdDuration(moov)
+	}
+}
+
+func mvhdDuration(moov []byte) (time.Duration, error) {
+	for len(moov) >= 8 {
+		size := int(binary.BigEndian.Uint32(moov))
+
+		if size < 8 || size > len(moov) {
+			break
+		}
+		if string(moov[4:8]) != "mvhd" {
+			moov = moov[size:]
+
+			continue
+		}
+
+		mvhd := moov[8:size]
+
+		if len(mvhd) < 20 || mvhd[0] != 0 {
+			return 0, errors.New("podcast: unsupported mvhd box")
+		}
+
+		timescale := binary.BigEndian.Uint32(mvhd[12:])
+		units := binary.BigEndian.Uint32(mvhd[16:])
+
+		if timescale == 0 {
+			return 0, errors.New("podcast: invalid timescale")
+		}
+
+		return time.Duration(units) * time.Second / time.Duration(timescale), nil
+	}
+
+	return 0, errors.New("podcast: mvhd box not found")
+}
+
+// adtsDuration counts the ADTS frames after the ID3 tag, if any.
+func adtsDuration(r io.ReadSeeker) (time.Duration, error) {
+	header := make([]byte, 10)
+
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, err
+	}
+
+	offset := int64(0)
+
+	if string(header[:3]) == "ID3" {
+		offset = 10 + (int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9]))
+	}
+
+	frames := int64(0)
+	sampleRate := 0
+
+	for {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if _, err := io.ReadFull(r, header[:7]); err != nil {
+			break
+		}
+
+		h, err := radiko.ParseADTSHeader(header[:7])
+
+		if err != nil || h.FrameLength <= 0 {
+			break
+		}
+
+		frames++
+		sampleRate = h.SampleRate()
+		offset += int64(h.FrameLength)
+	}
+	if sampleRate == 0 {
+		return 0, errors.New("podcast: no ADTS frame found")
+	}
+
+	return time.Duration(frames*1024) * time.Second / time.Duration(sampleRate), nil
+}
@@ -0,0 +1,155 @@
+package podcast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+)
+
+// Feed represents the channel of a podcast feed.
+type Feed struct {
+	Title       string
+	Description string
+
+	// Link is the URL of the web page of the feed.
+	Link string
+
+	// BaseURL is the URL of the scanned directory. The URLs of the files are made by joining it and the paths of the episodes.
+	BaseURL string
+
+	// Image is the URL of the artwork of the feed. The image of the newest episode is used by default.
+	Image string
+}
+
+type rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	ITunes  string   `xml:"xmlns:itunes,attr"`
+	Channel channel  `xml:"channel"`
+}
+
+type channel struct {
+	Title         string       `xml:"title"`
+	Link          string       `xml:"link"`
+	Description   string       `xml:"description"`
+	Language      string       `xml:"language"`
+	LastBuildDate string       `xml:"lastBuildDate"`
+	Author        string       `xml:"itunes:author,omitempty"`
+	Explicit      string       `xml:"itunes:explicit"`
+	Image         *itunesImage `xml:"itunes:image,omitempty"`
+	Items         []item       `xml:"item"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type item struct {
+	Title       string       `xml:"title"`
+	Description string       `xml:"description,omitempty"`
+	Link        string       `xml:"link,omitempty"`
+	GUID        guid         `xml:"guid"`
+	PubDate     string       `xml:"pubDate"`
+	Enclosure   enclosure    `xml:"enclosure"`
+	Author      string       `xml:"itunes:author,omitempty"`
+	Duration    string       `xml:"itunes:duration,omitempty"`
+	Image       *itunesImage `xml:"itunes:image,omitempty"`
+}
+
+type guid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// Write writes the feed of the episodes as RSS 2.0 with the iTunes extensions.
+func (f Feed) Write(w io.Writer, episodes []Episode) error {
+	base, err := url.Parse(strings.TrimSuffix(f.BaseURL, "/") + "/")
+
+	if err != nil {
+		return fmt.Errorf("podcast: invalid base URL: %w", err)
+	}
+
+	c := channel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Description,
+		Language:      "ja",
+		LastBuildDate: time.Now().Format(time.RFC1123Z),
+		Explicit:      "false",
+	}
+
+	if c.Link == "" {
+		c.Link = base.String()
+	}
+	if c.Description == "" {
+		c.Description = f.Title
+	}
+
+	image := f.Image
+
+	for _, episode := range episodes {
+		p := episode.Program
+		u := base.ResolveReference(&url.URL{Path: episode.Path})
+
+		it := item{
+			Title:       p.Title,
+			Description: p.Description,
+			Link:        p.URL,
+			GUID:        guid{Value: u.String()},
+			PubDate:     p.Start.Format(time.RFC1123Z),
+			Enclosure:   enclosure{URL: u.String(), Length: episode.Size, Type: episode.Type()},
+			Author:      strings.Join(p.Performers, ", "),
+		}
+
+		if !p.Start.IsZero() {
+			it.Title = fmt.Sprintf("%s %s", p.Title, p.Start.In(radiko.JST).Format("2006-01-02"))
+		}
+		if episode.Duration > 0 {
+			it.Duration = fmt.Sprint(int(episode.Duration.Round(time.Second).Seconds()))
+		}
+		if p.Image != "" {
+			it.Image = &itunesImage{Href: p.Image}
+
+			if image == "" {
+				image = p.Image
+			}
+		}
+		if c.Author == "" {
+			c.Author = p.StationName
+		}
+
+		c.Items = append(c.Items, it)
+	}
+	if image != "" {
+		c.Image = &itunesImage{Href: image}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("podcast: failed to write feed: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	v := rss{
+		Version: "2.0",
+		ITunes:  "http://www.itunes.com/dtds/podcast-1.0.dtd",
+		Channel: c,
+	}
+
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("podcast: failed to write feed: %w", err)
+	}
+
+	return nil
+}
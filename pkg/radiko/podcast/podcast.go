@@ -0,0 +1,166 @@
+/*
+Package podcast generates podcast feeds of recorded programs.
+
+Scan finds the recorded files in a directory and reads their metadata sidecars written by radiko.WithSidecar. The files without sidecar are described by their names, e.g. 'FMT_20231017-0600_Tokyo Breakfast.m4a'. Group splits the episodes per station or per program series, and Feed writes an RSS 2.0 feed with the iTunes extensions.
+*/
+package podcast
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+)
+
+// Episode represents a recorded file.
+type Episode struct {
+	// Path is the path of the file relative to the scanned directory, with slash separators.
+	Path     string
+	Size     int64
+	Duration time.Duration
+	Program  radiko.Program
+}
+
+// Type returns the MIME type of the file.
+func (e Episode) Type() string {
+	if strings.EqualFold(filepath.Ext(e.Path), ".aac") {
+		return "audio/aac"
+	}
+
+	return "audio/mp4"
+}
+
+// Scan returns the episodes of the recorded files in dir and its subdirectories, from the newest broadcast.
+func Scan(dir string) ([]Episode, error) {
+	var episodes []Episode
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+
+		if ext != ".m4a" && ext != ".aac" {
+			return nil
+		}
+
+		episode, err := scanFile(dir, path)
+
+		if err != nil {
+			return err
+		}
+
+		episodes = append(episodes, episode)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("podcast: failed to scan %s: %w", dir, err)
+	}
+
+	sort.SliceStable(episodes, func(i, j int) bool {
+		return episodes[i].Program.Start.After(episodes[j].Program.Start)
+	})
+
+	return episodes, nil
+}
+
+func scanFile(dir, path string) (Episode, error) {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return Episode{}, err
+	}
+
+	rel, err := filepath.Rel(dir, path)
+
+	if err != nil {
+		return Episode{}, err
+	}
+
+	episode := Episode{
+		Path: filepath.ToSlash(rel),
+		Size: info.Size(),
+	}
+
+	recording, err := radiko.ReadRecording(path)
+
+	if err != nil {
+		return Episode{}, err
+	}
+	if recording != nil {
+		episode.Program = recording.Program
+	} else {
+		episode.Program = parseFileName(filepath.Base(path))
+	}
+	if episode.Program.Start.IsZero() {
+		episode.Program.Start = info.ModTime()
+	}
+
+	// The duration of a broken file is left unknown.
+	if episode.Duration, err = duration(path); err != nil {
+		episode.Duration = episode.Program.Duration()
+	}
+
+	return episode, nil
+}
+
+// parseFileName returns the program described by the file name made by radiko.Program.FileName.
+func parseFileName(name string) radiko.Program {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	fields := strings.SplitN(name, "_", 3)
+
+	if len(fields) != 3 {
+		return radiko.Program{Title: name}
+	}
+
+	start, err := time.ParseInLocation("20060102-1504", fields[1], radiko.JST)
+
+	if err != nil {
+		return radiko.Program{Title: name}
+	}
+
+	return radiko.Program{
+		StationID: fields[0],
+		Start:     start,
+		Title:     fields[2],
+	}
+}
+
+// GroupBy represents how episodes are split into feeds.
+type GroupBy string
+
+const (
+	// GroupByStation makes a feed per station.
+	GroupByStation GroupBy = "station"
+
+	// GroupByProgram makes a feed per program series, which is identified by the station and the title.
+	GroupByProgram GroupBy = "program"
+)
+
+// Group returns the episodes grouped by the key, which is the station ID or the station ID and the title joined by '/'. The order of episodes is kept.
+func Group(episodes []Episode, by GroupBy) map[string][]Episode {
+	groups := map[string][]Episode{}
+
+	for _, episode := range episodes {
+		key := episode.Program.StationID
+
+		if by == GroupByProgram {
+			key += "/" + episode.Program.Title
+		}
+
+		groups[key] = append(groups[key], episode)
+	}
+
+	return groups
+}
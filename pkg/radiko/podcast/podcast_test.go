@@ -0,0 +1,93 @@
+package podcast
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+	"github.com/moutend/go-radiko/pkg/radiko/radikotest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeed(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+
+	server.Programs = []radikotest.Program{
+		{ID: "1", StationID: "FMT", Start: start, End: start.Add(10 * time.Second), Title: "Morning News", Performer: "Jane Doe"},
+		{ID: "2", StationID: "FMT", Start: start.Add(time.Minute), End: start.Add(80 * time.Second), Title: "Music"},
+	}
+
+	client := radiko.New("FMT", "", "", radiko.WithBaseURL(server.URL), radiko.WithStreamBaseURL(server.URL))
+
+	require.NoError(t, client.GetWeeklyPrograms(context.Background()))
+
+	dir := t.TempDir()
+
+	news, ok := client.Programs.At(start)
+
+	require.True(t, ok)
+	require.NoError(t, client.RecProgram(context.Background(), news, filepath.Join(dir, news.FileName(".m4a")), radiko.WithSidecar(news)))
+
+	// The file without sidecar is described by its name.
+	music, ok := client.Programs.At(start.Add(time.Minute))
+
+	require.True(t, ok)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "music"), 0755))
+	require.NoError(t, client.RecProgram(context.Background(), music, filepath.Join(dir, "music", music.FileName(".aac"))))
+
+	episodes, err := Scan(dir)
+
+	require.NoError(t, err)
+	require.Len(t, episodes, 2)
+	require.Equal(t, "Music", episodes[0].Program.Title)
+	require.Equal(t, "music/"+music.FileName(".aac"), episodes[0].Path)
+	require.True(t, music.Start.Equal(episodes[0].Program.Start))
+	require.Equal(t, 20*time.Second, episodes[0].Duration.Round(time.Second))
+	require.Equal(t, []string{"Jane Doe"}, episodes[1].Program.Performers)
+	require.Equal(t, 10*time.Second, episodes[1].Duration.Round(time.Second))
+
+	require.Len(t, Group(episodes, GroupByStation), 1)
+	require.Len(t, Group(episodes, GroupByProgram)["FMT/Music"], 1)
+
+	buf := &bytes.Buffer{}
+	feed := Feed{Title: "FMT", BaseURL: "https://example.com/radio/"}
+
+	require.NoError(t, feed.Write(buf, episodes))
+
+	var v struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Image struct {
+				Href string `xml:"href,attr"`
+			} `xml:"image"`
+			Items []struct {
+				Title     string `xml:"title"`
+				Duration  string `xml:"duration"`
+				Enclosure struct {
+					URL    string `xml:"url,attr"`
+					Length int64  `xml:"length,attr"`
+					Type   string `xml:"type,attr"`
+				} `xml:"enclosure"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &v))
+	require.Equal(t, "FMT", v.Channel.Title)
+	require.Equal(t, news.Image, v.Channel.Image.Href)
+	require.Len(t, v.Channel.Items, 2)
+	require.Equal(t, "20", v.Channel.Items[0].Duration)
+	require.Equal(t, "audio/aac", v.Channel.Items[0].Enclosure.Type)
+	require.Equal(t, "https://example.com/radio/music/"+music.FileName(".aac"), v.Channel.Items[0].Enclosure.URL)
+	require.Equal(t, episodes[1].Size, v.Channel.Items[1].Enclosure.Length)
+	require.Equal(t, "audio/mp4", v.Channel.Items[1].Enclosure.Type)
+}
@@ -0,0 +1,189 @@
+package radiko
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// JST is the time zone used by radiko.jp for all schedules.
+var JST = time.FixedZone("JST", 9*60*60)
+
+// BroadcastDate returns the date of the program guide which contains t, at 00:00 JST.
+//
+// A day on radiko.jp starts at 05:00, so the programs after midnight belong to the previous day.
+func BroadcastDate(t time.Time) time.Time {
+	t = t.In(JST).Add(-5 * time.Hour)
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, JST)
+}
+
+// ParseBroadcastDate parses the date with 'YYYY-MM-DD' layout in JST. An empty string means today's broadcast date.
+func ParseBroadcastDate(s string) (time.Time, error) {
+	if s == "" {
+		return BroadcastDate(time.Now()), nil
+	}
+
+	return time.ParseInLocation("2006-01-02", s, JST)
+}
+
+type ProgramXML struct {
+	Stations []ProgramStation `xml:"stations>station"`
+}
+
+type ProgramStation struct {
+	ID    string  `xml:"id,attr"`
+	Name  string  `xml:"name"`
+	Progs []Progs `xml:"progs"`
+}
+
+type Progs struct {
+	Date string `xml:"date"`
+	Prog []Prog `xml:"prog"`
+}
+
+type Prog struct {
+	ID          string `xml:"id,attr"`
+	From        string `xml:"ft,attr"`
+	To          string `xml:"to,attr"`
+	Duration    int    `xml:"dur,attr"`
+	Title       string `xml:"title"`
+	URL         string `xml:"url"`
+	Description string `xml:"desc"`
+	Info        string `xml:"info"`
+	Performer   string `xml:"pfm"`
+	Image       string `xml:"img"`
+}
+
+// Program represents a radio program in the program guide.
+//
+// Start and End are in JST.
+type Program struct {
+	ID          string    `json:"id"`
+	StationID   string    `json:"station_id"`
+	StationName string    `json:"station_name"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Title       string    `json:"title"`
+	Performers  []string  `json:"performers"`
+	Description string    `json:"description"`
+	Info        string    `json:"info"`
+	URL         string    `json:"url"`
+	Image       string    `json:"image"`
+}
+
+// Duration returns the length of the program.
+func (p Program) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// FileName returns a file name derived from the station, the start time and the title, e.g. 'FMT_20231017-0600_Tokyo Breakfast.m4a'.
+func (p Program) FileName(ext string) string {
+	title := SanitizeFileName(strings.TrimSpace(p.Title))
+
+	return fmt.Sprintf("%s_%s_%s%s", p.StationID, p.Start.In(JST).Format("20060102-1504"), title, ext)
+}
+
+// SanitizeFileName replaces the characters which can't be used in a file name on Windows with '_', and removes the control characters.
+func SanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < 0x20 {
+			return -1
+		}
+
+		return r
+	}, s)
+}
+
+type ProgramSlice []Program
+
+// Search returns the programs whose ID is query or whose title contains query. The title is compared case-insensitively.
+func (s ProgramSlice) Search(query string) ProgramSlice {
+	var programs ProgramSlice
+
+	for i := range s {
+		if s[i].ID == query || strings.Contains(strings.ToLower(s[i].Title), strings.ToLower(query)) {
+			programs = append(programs, s[i])
+		}
+	}
+
+	return programs
+}
+
+// At returns the program on air at the specified time.
+func (s ProgramSlice) At(t time.Time) (Program, bool) {
+	for i := range s {
+		if !t.Before(s[i].Start) && t.Before(s[i].End) {
+			return s[i], true
+		}
+	}
+
+	return Program{}, false
+}
+
+// ParseProgramXML parses a program guide such as the daily or weekly schedule of a station.
+func ParseProgramXML(r io.Reader) (ProgramSlice, error) {
+	var v ProgramXML
+
+	if err := xml.NewDecoder(r).Decode(&v); err != nil {
+		return nil, fmt.Errorf("radiko: failed to parse XML: %w", err)
+	}
+
+	var programs ProgramSlice
+
+	for i := range v.Stations {
+		for j := range v.Stations[i].Progs {
+			for _, prog := range v.Stations[i].Progs[j].Prog {
+				start, err := time.ParseInLocation("20060102150405", prog.From, JST)
+
+				if err != nil {
+					return nil, fmt.Errorf("radiko: failed to parse start time: %w", err)
+				}
+
+				end, err := time.ParseInLocation("20060102150405", prog.To, JST)
+
+				if err != nil {
+					return nil, fmt.Errorf("radiko: failed to parse end time: %w", err)
+				}
+
+				programs = append(programs, Program{
+					ID:          prog.ID,
+					StationID:   v.Stations[i].ID,
+					StationName: v.Stations[i].Name,
+					Start:       start,
+					End:         end,
+					Title:       prog.Title,
+					Performers:  splitPerformers(prog.Performer),
+					Description: prog.Description,
+					Info:        prog.Info,
+					URL:         prog.URL,
+					Image:       prog.Image,
+				})
+			}
+		}
+	}
+
+	return programs, nil
+}
+
+func splitPerformers(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '、' || r == ',' || r == '，'
+	})
+
+	var performers []string
+
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			performers = append(performers, field)
+		}
+	}
+
+	return performers
+}
@@ -0,0 +1,105 @@
+package radiko
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProgramXML(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "Program.xml"))
+
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	programs, err := ParseProgramXML(file)
+
+	require.NoError(t, err)
+	require.Len(t, programs, 5)
+
+	for i := range programs {
+		require.NotEmpty(t, programs[i].ID)
+		require.NotEmpty(t, programs[i].Title)
+		require.Equal(t, "FMT", programs[i].StationID)
+		require.True(t, programs[i].Start.Before(programs[i].End))
+	}
+
+	require.Equal(t, time.Date(2023, 10, 16, 20, 0, 0, 0, time.UTC), programs[0].Start.UTC())
+	require.Equal(t, time.Hour, programs[0].Duration())
+	require.Equal(t, []string{"山田太郎", "鈴木花子"}, programs[0].Performers)
+	require.Equal(t, "News, weather and traffic.", programs[1].Description)
+	require.Empty(t, programs[2].Performers)
+}
+
+func TestProgramSliceAt(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "Program.xml"))
+
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	programs, err := ParseProgramXML(file)
+
+	require.NoError(t, err)
+
+	program, ok := programs.At(time.Date(2023, 10, 17, 6, 0, 0, 0, JST))
+
+	require.True(t, ok)
+	require.Equal(t, "Tokyo Breakfast", program.Title)
+
+	_, ok = programs.At(time.Date(2023, 10, 17, 4, 59, 59, 0, JST))
+
+	require.False(t, ok)
+}
+
+func TestProgramSliceSearch(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "Program.xml"))
+
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	programs, err := ParseProgramXML(file)
+
+	require.NoError(t, err)
+	require.Len(t, programs.Search("morning glory"), 2)
+	require.Len(t, programs.Search("15373751"), 1)
+	require.Empty(t, programs.Search("unknown"))
+}
+
+func TestProgramFileName(t *testing.T) {
+	program := Program{
+		StationID: "FMT",
+		Start:     time.Date(2023, 10, 16, 21, 0, 0, 0, time.UTC),
+		Title:     " AC/DC: Live? ",
+	}
+
+	require.Equal(t, "FMT_20231017-0600_AC_DC_ Live_.m4a", program.FileName(".m4a"))
+	require.Equal(t, "FMT_Night Show_", SanitizeFileName("FMT/Night Show\t|"))
+}
+
+func TestBroadcastDate(t *testing.T) {
+	require.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, JST), BroadcastDate(time.Date(2026, 10, 18, 4, 59, 0, 0, JST)))
+	require.Equal(t, time.Date(2026, 10, 18, 0, 0, 0, 0, JST), BroadcastDate(time.Date(2026, 10, 18, 5, 0, 0, 0, JST)))
+	require.Equal(t, time.Date(2026, 10, 18, 0, 0, 0, 0, JST), BroadcastDate(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestParseBroadcastDate(t *testing.T) {
+	date, err := ParseBroadcastDate("2026-10-17")
+
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, JST), date)
+
+	date, err = ParseBroadcastDate("")
+
+	require.NoError(t, err)
+	require.Equal(t, BroadcastDate(time.Now()), date)
+
+	_, err = ParseBroadcastDate("2026/10/17")
+
+	require.Error(t, err)
+}
@@ -0,0 +1,622 @@
+/*
+Package radikotest provides an in-process fake radiko.jp server for testing.
+
+The server implements the authentication flow, the station list, the program guide and the HLS streams. Use it with radiko.WithBaseURL and radiko.WithStreamBaseURL options:
+
+	server := radikotest.NewServer()
+	defer server.Close()
+
+	client := radiko.New("FMT", "", "", radiko.WithBaseURL(server.URL), radiko.WithStreamBaseURL(server.URL))
+*/
+package radikotest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "embed"
+)
+
+// Endpoint identifies an API of the server.
+type Endpoint string
+
+const (
+	EndpointArea         Endpoint = "area"
+	EndpointSeed         Endpoint = "seed"
+	EndpointLogin        Endpoint = "login"
+	EndpointCheck        Endpoint = "check"
+	EndpointAuth1        Endpoint = "auth1"
+	EndpointAuth2        Endpoint = "auth2"
+	EndpointStations     Endpoint = "stations"
+	EndpointAreaStations Endpoint = "area_stations"
+	EndpointPlaylist     Endpoint = "playlist"
+	EndpointPrograms     Endpoint = "programs"
+	EndpointSongs        Endpoint = "songs"
+	EndpointLive         Endpoint = "live"
+	EndpointTimefree     Endpoint = "timefree"
+	EndpointSegment      Endpoint = "segment"
+	EndpointImage        Endpoint = "image"
+)
+
+// FullKey is the seed string embedded in playerCommon.js.
+const FullKey = "bcd151073c03b352e1ef2fd66c32209da9ca0afa"
+
+const (
+	keyOffset = 8
+	keyLength = 16
+)
+
+//go:embed testdata/full.xml
+var fullXML []byte
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+// Program is a program served by the program guide endpoints.
+type Program struct {
+	ID        string
+	StationID string
+	Start     time.Time
+	End       time.Time
+	Title     string
+	Performer string
+}
+
+type failure struct {
+	statusCode int
+	remaining  int
+}
+
+// Server is a fake radiko.jp server.
+//
+// The fields must be set before the client sends requests.
+type Server struct {
+	*httptest.Server
+
+	// AreaID is the area detected from the IP. The default is 'JP13'.
+	AreaID string
+
+	// Username and Password are the credentials of the premium member.
+	Username string
+	Password string
+
+	// SegmentDuration is the length of each HLS segment. The default is 5 seconds.
+	SegmentDuration time.Duration
+
+	// Programs are served by the program guide endpoints.
+	// If a station has no program, hourly programs are generated.
+	Programs []Program
+
+	mu       sync.Mutex
+	tokens   map[string]bool
+	issued   int
+	failures map[Endpoint]*failure
+	requests map[Endpoint]int
+}
+
+// NewServer starts and returns a server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		AreaID:          "JP13",
+		SegmentDuration: 5 * time.Second,
+		tokens:          map[string]bool{},
+		failures:        map[Endpoint]*failure{},
+		requests:        map[Endpoint]int{},
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/area", s.handle(EndpointArea, s.area))
+	mux.HandleFunc("/apps/js/playerCommon.js", s.handle(EndpointSeed, s.seed))
+	mux.HandleFunc("/v4/api/member/login", s.handle(EndpointLogin, s.login))
+	mux.HandleFunc("/ap/member/webapi/v2/member/login/check", s.handle(EndpointCheck, s.check))
+	mux.HandleFunc("/v2/api/auth1", s.handle(EndpointAuth1, s.auth1))
+	mux.HandleFunc("/v2/api/auth2", s.handle(EndpointAuth2, s.auth2))
+	mux.HandleFunc("/v3/station/region/full.xml", s.handle(EndpointStations, s.stations))
+	mux.HandleFunc("/v3/station/list/", s.handle(EndpointAreaStations, s.areaStations))
+	mux.HandleFunc("/v3/station/stream/pc_html5/", s.handle(EndpointPlaylist, s.playlist))
+	mux.HandleFunc("/v3/program/station/", s.handle(EndpointPrograms, s.programs))
+	mux.HandleFunc("/v3/feed/pc/noa/", s.handle(EndpointSongs, s.songs))
+	mux.HandleFunc("/so/", s.handle(EndpointLive, s.authorized(s.live)))
+	mux.HandleFunc("/tf/", s.handle(EndpointTimefree, s.authorized(s.timefree)))
+	mux.HandleFunc("/segment/", s.handle(EndpointSegment, s.authorized(s.segment)))
+	mux.HandleFunc("/image/", s.handle(EndpointImage, s.image))
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Fail makes the endpoint respond with the status code for the next count requests.
+//
+// If count is 0 or less, the endpoint keeps failing until Recover is called.
+func (s *Server) Fail(endpoint Endpoint, statusCode, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[endpoint] = &failure{
+		statusCode: statusCode,
+		remaining:  count,
+	}
+}
+
+// Recover makes the failing endpoint work again.
+func (s *Server) Recover(endpoint Endpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, endpoint)
+}
+
+// Requests returns the number of requests the endpoint received.
+func (s *Server) Requests(endpoint Endpoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[endpoint]
+}
+
+// ExpireTokens invalidates all auth tokens issued so far.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = map[string]bool{}
+}
+
+func (s *Server) handle(endpoint Endpoint, fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+
+		s.requests[endpoint]++
+
+		f := s.failures[endpoint]
+		statusCode := 0
+
+		if f != nil {
+			statusCode = f.statusCode
+
+			if f.remaining > 0 {
+				if f.remaining--; f.remaining == 0 {
+					delete(s.failures, endpoint)
+				}
+			}
+		}
+
+		s.mu.Unlock()
+
+		if statusCode != 0 {
+			http.Error(w, http.StatusText(statusCode), statusCode)
+
+			return
+		}
+
+		fn(w, r)
+	}
+}
+
+func (s *Server) authorized(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		ok := s.tokens[r.Header.Get("X-Radiko-AuthToken")]
+		s.mu.Unlock()
+
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+			return
+		}
+
+		fn(w, r)
+	}
+}
+
+func (s *Server) area(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, `document.write('<span class="%s">TOKYO JAPAN</span>');`, s.AreaID)
+}
+
+func (s *Server) seed(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "var player = new RadikoJSPlayer($audio[0], 'pc_html5', '%s', {\n", FullKey)
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+	if s.Username == "" || r.PostFormValue("mail") != s.Username || r.PostFormValue("pass") != s.Password {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"radiko_session": "premium-session",
+	})
+}
+
+func (s *Server) check(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: "radiko_session", Value: "normal-session"})
+
+	// The real API responds 400 Bad Request to normal members.
+	w.WriteHeader(http.StatusBadRequest)
+}
+
+func (s *Server) auth1(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Radiko-App") == "" || r.Header.Get("X-Radiko-Device") == "" {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	s.mu.Lock()
+	s.issued++
+	token := fmt.Sprintf("token-%d", s.issued)
+	s.mu.Unlock()
+
+	w.Header().Set("X-Radiko-AuthToken", token)
+	w.Header().Set("X-Radiko-KeyOffset", strconv.Itoa(keyOffset))
+	w.Header().Set("X-Radiko-KeyLength", strconv.Itoa(keyLength))
+}
+
+func (s *Server) auth2(w http.ResponseWriter, r *http.Request) {
+	partialKey := base64.StdEncoding.EncodeToString([]byte(FullKey[keyOffset : keyOffset+keyLength]))
+	token := r.Header.Get("X-Radiko-AuthToken")
+
+	if token == "" || r.Header.Get("X-Radiko-PartialKey") != partialKey {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+		return
+	}
+
+	s.mu.Lock()
+	s.tokens[token] = true
+	s.mu.Unlock()
+
+	fmt.Fprintf(w, "%s,TOKYO JAPAN,tokyo Japan\n", s.AreaID)
+}
+
+func (s *Server) stations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/xml")
+
+	// The logos are served by the image endpoint.
+	w.Write(bytes.ReplaceAll(fullXML, []byte("https://example.com/v2/static/station/logo/"), []byte(s.URL+"/image/logo/")))
+}
+
+type station struct {
+	ID        string `xml:"id"`
+	Name      string `xml:"name"`
+	AsciiName string `xml:"ascii_name"`
+	Ruby      string `xml:"ruby"`
+	Areafree  int    `xml:"areafree"`
+	Timefree  int    `xml:"timefree"`
+	AreaID    string `xml:"area_id"`
+}
+
+// areaStations serves '/v3/station/list/{area}.xml' with the stations of the area in full.xml.
+func (s *Server) areaStations(w http.ResponseWriter, r *http.Request) {
+	areaID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v3/station/list/"), ".xml")
+
+	var region struct {
+		Stations []struct {
+			Station []station `xml:"station"`
+		} `xml:"stations"`
+	}
+
+	if err := xml.Unmarshal(fullXML, &region); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	response := struct {
+		XMLName  xml.Name  `xml:"stations"`
+		AreaID   string    `xml:"area_id,attr"`
+		AreaName string    `xml:"area_name,attr"`
+		Station  []station `xml:"station"`
+	}{
+		AreaID:   areaID,
+		AreaName: "TOKYO JAPAN",
+	}
+
+	for _, stations := range region.Stations {
+		for _, st := range stations.Station {
+			if st.AreaID == areaID {
+				response.Station = append(response.Station, st)
+			}
+		}
+	}
+	if len(response.Station) == 0 {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) playlist(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/xml")
+
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8" ?>
+<urls>
+  <url areafree="0" max_delay="60" timefree="0">
+    <playlist_create_url>%[1]s/so/playlist.m3u8</playlist_create_url>
+  </url>
+  <url areafree="0" max_delay="60" timefree="1">
+    <playlist_create_url>%[1]s/tf/playlist.m3u8</playlist_create_url>
+  </url>
+</urls>
+`, s.URL)
+}
+
+// programs serves '/v3/program/station/date/{date}/{station}.xml' and '/v3/program/station/weekly/{station}.xml'.
+func (s *Server) programs(w http.ResponseWriter, r *http.Request) {
+	tokens := strings.Split(strings.TrimPrefix(r.URL.Path, "/v3/program/station/"), "/")
+
+	var (
+		stationID string
+		days      []time.Time
+	)
+
+	switch {
+	case len(tokens) == 3 && tokens[0] == "date":
+		date, err := time.ParseInLocation("20060102", tokens[1], jst)
+
+		if err != nil {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		stationID = strings.TrimSuffix(tokens[2], ".xml")
+		days = []time.Time{date}
+	case len(tokens) == 2 && tokens[0] == "weekly":
+		now := time.Now().In(jst).Add(-5 * time.Hour)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, jst)
+
+		stationID = strings.TrimSuffix(tokens[1], ".xml")
+
+		for i := -6; i <= 7; i++ {
+			days = append(days, today.AddDate(0, 0, i))
+		}
+	default:
+		http.NotFound(w, r)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+
+	fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<radiko>\n  <stations>\n    <station id=\"%s\">\n      <name>%s</name>\n", stationID, stationID)
+
+	for _, day := range days {
+		fmt.Fprintf(w, "      <progs>\n        <date>%s</date>\n", day.Format("20060102"))
+
+		for _, p := range s.programsOf(stationID, day) {
+			fmt.Fprintf(
+				w,
+				"        <prog id=\"%s\" ft=\"%s\" to=\"%s\" dur=\"%d\">\n          <title>%s</title>\n          <pfm>%s</pfm>\n          <url>https://example.com/%s</url>\n          <img>%s/image/%s.png</img>\n        </prog>\n",
+				p.ID,
+				p.Start.In(jst).Format("20060102150405"),
+				p.End.In(jst).Format("20060102150405"),
+				int(p.End.Sub(p.Start).Seconds()),
+				html.EscapeString(p.Title),
+				html.EscapeString(p.Performer),
+				p.ID,
+				s.URL,
+				p.ID,
+			)
+		}
+
+		fmt.Fprint(w, "      </progs>\n")
+	}
+
+	fmt.Fprint(w, "    </station>\n  </stations>\n</radiko>\n")
+}
+
+// programsOf returns the programs of the station on the day starting at 05:00.
+func (s *Server) programsOf(stationID string, day time.Time) []Program {
+	start := day.Add(5 * time.Hour)
+	end := start.Add(24 * time.Hour)
+
+	var programs []Program
+
+	for _, p := range s.Programs {
+		if p.StationID == stationID && !p.Start.Before(start) && p.Start.Before(end) {
+			programs = append(programs, p)
+		}
+	}
+	if len(programs) > 0 {
+		return programs
+	}
+	for _, p := range s.Programs {
+		if p.StationID == stationID {
+			return nil
+		}
+	}
+	for t := start; t.Before(end); t = t.Add(time.Hour) {
+		programs = append(programs, Program{
+			ID:        fmt.Sprintf("%s-%s", stationID, t.Format("2006010215")),
+			StationID: stationID,
+			Start:     t,
+			End:       t.Add(time.Hour),
+			Title:     fmt.Sprintf("%s %s", stationID, t.Format("15:04")),
+			Performer: "Test Performer",
+		})
+	}
+
+	return programs
+}
+
+func (s *Server) songs(w http.ResponseWriter, r *http.Request) {
+	stamp := time.Now().In(jst).Add(-3 * time.Minute)
+
+	w.Header().Set("Content-Type", "application/xml")
+
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<noa>
+  <item stamp="%s" title="Test Song" artist="Test Artist" program_title="Test Program" img="https://example.com/song.jpg" img_large="" />
+</noa>
+`, stamp.Format("2006-01-02 15:04:05"))
+}
+
+// live serves '/so/playlist.m3u8' and '/so/chunklist.m3u8' with the last three segments.
+func (s *Server) live(w http.ResponseWriter, r *http.Request) {
+	stationID := r.URL.Query().Get("station_id")
+
+	if strings.HasSuffix(r.URL.Path, "/playlist.m3u8") {
+		writeMaster(w, fmt.Sprintf("%s/so/chunklist.m3u8?station_id=%s", s.URL, stationID))
+
+		return
+	}
+
+	sequence := int(time.Now().UnixNano() / int64(s.SegmentDuration))
+
+	writeMedia(w, s.segmentURLs(stationID, sequence-2, sequence+1), sequence-2, s.SegmentDuration, false)
+}
+
+// timefree serves '/tf/playlist.m3u8' and '/tf/chunklist.m3u8' with all segments between start_at and end_at.
+func (s *Server) timefree(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if strings.HasSuffix(r.URL.Path, "/playlist.m3u8") {
+		writeMaster(w, fmt.Sprintf("%s/tf/chunklist.m3u8?%s", s.URL, r.URL.RawQuery))
+
+		return
+	}
+
+	start, err := time.ParseInLocation("20060102150405", query.Get("start_at"), jst)
+
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	end, err := time.ParseInLocation("20060102150405", query.Get("end_at"), jst)
+
+	if err != nil || !start.Before(end) {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	first := int(start.UnixNano() / int64(s.SegmentDuration))
+	last := int((end.UnixNano() + int64(s.SegmentDuration) - 1) / int64(s.SegmentDuration))
+
+	writeMedia(w, s.segmentURLs(query.Get("station_id"), first, last), first, s.SegmentDuration, true)
+}
+
+func (s *Server) segmentURLs(stationID string, first, last int) []string {
+	var urls []string
+
+	for i := first; i < last; i++ {
+		urls = append(urls, fmt.Sprintf("%s/segment/%s/%d.aac", s.URL, stationID, i))
+	}
+
+	return urls
+}
+
+// image serves '/image/{name}.png' with a 1x1 PNG image.
+func (s *Server) image(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/png")
+
+	png.Encode(w, image.NewGray(image.Rect(0, 0, 1, 1)))
+}
+
+// segment serves '/segment/{station}/{sequence}.aac'.
+func (s *Server) segment(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	sequence, err := strconv.Atoi(strings.TrimSuffix(name, ".aac"))
+
+	if err != nil {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/aac")
+	w.Write(Segment(sequence, s.SegmentDuration))
+}
+
+func writeMaster(w http.ResponseWriter, u string) {
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+
+	fmt.Fprintf(w, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-STREAM-INF:BANDWIDTH=52973,CODECS=\"mp4a.40.5\"\n%s\n", u)
+}
+
+func writeMedia(w http.ResponseWriter, urls []string, sequence int, duration time.Duration, endList bool) {
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+
+	targetDuration := int((duration + time.Second - 1) / time.Second)
+
+	fmt.Fprintf(w, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:%d\n", targetDuration, sequence)
+
+	for _, u := range urls {
+		fmt.Fprintf(w, "#EXTINF:%.3f,\n%s\n", duration.Seconds(), u)
+	}
+	if endList {
+		fmt.Fprint(w, "#EXT-X-ENDLIST\n")
+	}
+}
+
+// Segment returns the content of a segment: an ID3 tag followed by silent ADTS frames of AAC LC, 24 kHz and stereo.
+//
+// The first byte of each frame payload is the lowest byte of the sequence, so that the order of segments can be verified.
+func Segment(sequence int, duration time.Duration) []byte {
+	const sampleRate = 24000
+
+	frames := int(duration.Seconds() * sampleRate / 1024)
+
+	if frames < 1 {
+		frames = 1
+	}
+
+	// ID3v2.4 header with 0 byte payload.
+	data := []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 0}
+
+	for i := 0; i < frames; i++ {
+		payload := []byte{byte(sequence), 0x21, 0x10, 0x04, 0x60, 0x8c, 0x1c}
+		data = append(data, ADTSHeader(len(payload))...)
+		data = append(data, payload...)
+	}
+
+	return data
+}
+
+// ADTSHeader returns a header of an ADTS frame of AAC LC, 24 kHz and stereo.
+func ADTSHeader(payloadLength int) []byte {
+	const (
+		profile = 1
+		index   = 6
+		channel = 2
+	)
+
+	length := payloadLength + 7
+
+	return []byte{
+		0xff,
+		0xf1,
+		profile<<6 | index<<2 | channel>>2,
+		byte((channel&3)<<6 | length>>11&0x03),
+		byte(length >> 3),
+		byte(length&0x07<<5 | 0x1f),
+		0xfc,
+	}
+}
@@ -0,0 +1,102 @@
+package radiko
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ReconnectPolicy controls how the live stream recovers from errors such as an expired auth token or a dropped connection.
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of consecutive reconnections before giving up. 0 disables reconnection.
+	MaxAttempts int
+
+	// Delay is the wait before the first reconnection. It doubles on every consecutive failure.
+	Delay time.Duration
+
+	// MaxDelay caps the wait between reconnections.
+	MaxDelay time.Duration
+
+	// StallTimeout aborts a playlist or segment request which takes longer than this, then the stream reconnects. 0 disables the timeout.
+	StallTimeout time.Duration
+}
+
+// DefaultReconnectPolicy is the reconnect policy of the live stream used by default.
+var DefaultReconnectPolicy = ReconnectPolicy{
+	MaxAttempts:  5,
+	Delay:        time.Second,
+	MaxDelay:     30 * time.Second,
+	StallTimeout: 30 * time.Second,
+}
+
+// WithReconnectPolicy sets the reconnect policy of the live stream used by Play and RecLive. The default is DefaultReconnectPolicy.
+func WithReconnectPolicy(policy ReconnectPolicy) Option {
+	return func(c *Client) {
+		c.reconnect = policy
+	}
+}
+
+// delay returns the wait before the reconnection of the attempt, which starts at 0.
+func (p ReconnectPolicy) delay(attempt int) time.Duration {
+	d := p.Delay
+
+	for i := 0; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	return d
+}
+
+// openLiveStream opens the live stream which reconnects according to the reconnect policy of the client.
+func (c *Client) openLiveStream(ctx context.Context) (*Stream, error) {
+	stream, err := c.openStream(ctx, c.liveURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stream.reconnect = c.reconnect
+
+	return stream, nil
+}
+
+// Next downloads the next segment and returns its ADTS audio.
+//
+// It blocks until a new segment is published when the stream is live. When the stream reconnects, it authenticates again if the session has been rejected or expired, and continues from the next media sequence.
+func (s *Stream) Next() ([]byte, Segment, error) {
+	for attempt := 0; ; attempt++ {
+		data, segment, err := s.next()
+
+		if err == nil || errors.Is(err, io.EOF) || s.ctx.Err() != nil || attempt >= s.reconnect.MaxAttempts {
+			return data, segment, err
+		}
+
+		d := s.reconnect.delay(attempt)
+
+		s.client.debug.Printf("stream: reconnecting in %s (%d/%d): %v\n", d, attempt+1, s.reconnect.MaxAttempts, err)
+
+		if err := s.sleep(d); err != nil {
+			return nil, Segment{}, err
+		}
+
+		// The session is discarded when the token is rejected, then it is issued again.
+		reused, err := s.client.authenticate(s.ctx)
+
+		if err != nil {
+			s.client.debug.Printf("stream: failed to authenticate: %v\n", err)
+
+			continue
+		}
+		if !reused {
+			s.client.debug.Println("stream: authenticated again")
+		}
+
+		// The playlist is loaded from the master playlist again and the segments after the last one are kept.
+		s.mediaURL = ""
+		s.segments = nil
+	}
+}
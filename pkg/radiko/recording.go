@@ -0,0 +1,56 @@
+package radiko
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Recording is the metadata of a recorded file, which is saved next to the file as a JSON sidecar.
+type Recording struct {
+	Program    Program   `json:"program"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// SidecarPath returns the path of the metadata sidecar of the recorded file, e.g. 'FMT_20231017-0600_Title.json' for 'FMT_20231017-0600_Title.m4a'.
+func SidecarPath(file string) string {
+	return strings.TrimSuffix(file, filepath.Ext(file)) + ".json"
+}
+
+// ReadRecording reads the metadata sidecar of the recorded file. It returns nil if the sidecar doesn't exist.
+func ReadRecording(file string) (*Recording, error) {
+	data, err := os.ReadFile(SidecarPath(file))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("radiko: failed to read sidecar: %w", err)
+	}
+
+	var recording Recording
+
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("radiko: failed to parse sidecar: %w", err)
+	}
+
+	return &recording, nil
+}
+
+func writeRecording(file string, recording Recording) error {
+	data, err := json.MarshalIndent(recording, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("radiko: failed to encode sidecar: %w", err)
+	}
+	if err := os.WriteFile(SidecarPath(file), data, 0644); err != nil {
+		return fmt.Errorf("radiko: failed to write sidecar: %w", err)
+	}
+
+	return nil
+}
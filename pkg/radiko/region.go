@@ -0,0 +1,99 @@
+package radiko
+
+import "strings"
+
+// Region is a group of stations such as 'kanto'.
+type Region struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	AsciiName string       `json:"ascii_name"`
+	Stations  StationSlice `json:"stations"`
+}
+
+// Prefectures maps the area IDs to the prefecture names.
+var Prefectures = map[string]string{
+	"JP1":  "北海道",
+	"JP2":  "青森県",
+	"JP3":  "岩手県",
+	"JP4":  "宮城県",
+	"JP5":  "秋田県",
+	"JP6":  "山形県",
+	"JP7":  "福島県",
+	"JP8":  "茨城県",
+	"JP9":  "栃木県",
+	"JP10": "群馬県",
+	"JP11": "埼玉県",
+	"JP12": "千葉県",
+	"JP13": "東京都",
+	"JP14": "神奈川県",
+	"JP15": "新潟県",
+	"JP16": "富山県",
+	"JP17": "石川県",
+	"JP18": "福井県",
+	"JP19": "山梨県",
+	"JP20": "長野県",
+	"JP21": "岐阜県",
+	"JP22": "静岡県",
+	"JP23": "愛知県",
+	"JP24": "三重県",
+	"JP25": "滋賀県",
+	"JP26": "京都府",
+	"JP27": "大阪府",
+	"JP28": "兵庫県",
+	"JP29": "奈良県",
+	"JP30": "和歌山県",
+	"JP31": "鳥取県",
+	"JP32": "島根県",
+	"JP33": "岡山県",
+	"JP34": "広島県",
+	"JP35": "山口県",
+	"JP36": "徳島県",
+	"JP37": "香川県",
+	"JP38": "愛媛県",
+	"JP39": "高知県",
+	"JP40": "福岡県",
+	"JP41": "佐賀県",
+	"JP42": "長崎県",
+	"JP43": "熊本県",
+	"JP44": "大分県",
+	"JP45": "宮崎県",
+	"JP46": "鹿児島県",
+	"JP47": "沖縄県",
+}
+
+// NormalizeAreaID returns the area ID such as 'JP13' from '13', 'jp13' or 'JP13'.
+func NormalizeAreaID(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	if !strings.HasPrefix(s, "JP") {
+		s = "JP" + s
+	}
+
+	return s
+}
+
+// InRegion returns the stations of the region. The region ID such as 'kanto' is case-insensitive.
+func (s StationSlice) InRegion(regionID string) StationSlice {
+	var stations StationSlice
+
+	for i := range s {
+		if strings.EqualFold(s[i].RegionID, regionID) {
+			stations = append(stations, s[i])
+		}
+	}
+
+	return stations
+}
+
+// InArea returns the stations broadcasting in the area such as 'JP13'.
+func (s StationSlice) InArea(areaID string) StationSlice {
+	var stations StationSlice
+
+	for i := range s {
+		if s[i].AreaID == areaID {
+			stations = append(stations, s[i])
+		}
+	}
+
+	return stations
+}
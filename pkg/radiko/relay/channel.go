@@ -0,0 +1,228 @@
+package relay
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+)
+
+// listenerBuffer is the number of segments buffered for a listener. A listener which falls behind more than this is disconnected.
+const listenerBuffer = 16
+
+// segment is a segment kept for the HLS clients.
+type segment struct {
+	sequence int
+	duration time.Duration
+	data     []byte
+}
+
+// channel shares a live stream of a station among the listeners.
+type channel struct {
+	stationID string
+	ready     chan struct{}
+	openErr   error
+
+	mu         sync.Mutex
+	listeners  map[chan []byte]struct{}
+	segments   []segment
+	timestamp  uint64
+	lastAccess time.Time
+	updated    chan struct{}
+	err        error
+}
+
+func newChannel(stationID string) *channel {
+	return &channel{
+		stationID:  stationID,
+		ready:      make(chan struct{}),
+		listeners:  map[chan []byte]struct{}{},
+		lastAccess: time.Now(),
+		updated:    make(chan struct{}),
+	}
+}
+
+// run reads the stream and sends the segments to the listeners until ctx is done or the stream fails.
+func (c *channel) run(stream *radiko.Stream, keep int, idle func(*channel) bool) {
+	for {
+		data, s, err := stream.Next()
+
+		if err != nil {
+			c.close(err)
+
+			return
+		}
+
+		c.mu.Lock()
+
+		for listener := range c.listeners {
+			select {
+			case listener <- data:
+			default:
+				// The listener is too slow to follow the live stream.
+				delete(c.listeners, listener)
+				close(listener)
+			}
+		}
+
+		// The packed audio of HLS must begin with the timestamp, which is stripped from the stream.
+		c.segments = append(c.segments, segment{sequence: s.Sequence, duration: s.Duration, data: append(timestampID3(c.timestamp), data...)})
+		c.timestamp += audioTicks(data, s.Duration)
+
+		if len(c.segments) > keep {
+			c.segments = c.segments[len(c.segments)-keep:]
+		}
+
+		close(c.updated)
+		c.updated = make(chan struct{})
+
+		c.mu.Unlock()
+
+		if idle(c) {
+			c.close(context.Canceled)
+
+			return
+		}
+	}
+}
+
+// close disconnects all listeners.
+func (c *channel) close(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for listener := range c.listeners {
+		close(listener)
+	}
+
+	c.listeners = map[chan []byte]struct{}{}
+	c.err = err
+
+	close(c.updated)
+	c.updated = make(chan struct{})
+}
+
+// alive reports whether the channel is still reading the stream.
+func (c *channel) alive() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err == nil
+}
+
+// idle reports whether no one has listened to the channel for d.
+func (c *channel) idle(d time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.listeners) == 0 && time.Since(c.lastAccess) > d
+}
+
+func (c *channel) subscribe() (chan []byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, false
+	}
+
+	listener := make(chan []byte, listenerBuffer)
+	c.listeners[listener] = struct{}{}
+
+	return listener, true
+}
+
+func (c *channel) unsubscribe(listener chan []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.listeners[listener]; ok {
+		delete(c.listeners, listener)
+		close(listener)
+	}
+
+	c.lastAccess = time.Now()
+}
+
+// playlist returns the segments kept for the HLS clients. It waits for the first segment.
+func (c *channel) playlist(ctx context.Context) ([]segment, error) {
+	for {
+		c.mu.Lock()
+
+		c.lastAccess = time.Now()
+		segments := append([]segment(nil), c.segments...)
+		updated := c.updated
+		err := c.err
+
+		c.mu.Unlock()
+
+		if len(segments) > 0 || err != nil {
+			return segments, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-updated:
+		}
+	}
+}
+
+func (c *channel) segment(sequence int) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastAccess = time.Now()
+
+	for _, s := range c.segments {
+		if s.sequence == sequence {
+			return s.data, true
+		}
+	}
+
+	return nil, false
+}
+
+// timestampID3 returns the ID3 tag which carries the timestamp of the segment in 90kHz, as required by the packed audio of HLS.
+func timestampID3(timestamp uint64) []byte {
+	owner := "com.apple.streaming.transportStreamTimestamp\x00"
+
+	// The timestamp is a 33-bit MPEG-2 PTS.
+	payload := binary.BigEndian.AppendUint64([]byte(owner), timestamp&(1<<33-1))
+	frame := append([]byte("PRIV"), syncsafe(len(payload))...)
+	frame = append(frame, 0, 0)
+	frame = append(frame, payload...)
+	tag := append([]byte("ID3\x04\x00\x00"), syncsafe(len(frame))...)
+
+	return append(tag, frame...)
+}
+
+// audioTicks returns the duration of the ADTS audio in 90kHz. The duration in the playlist is used when the audio can't be parsed.
+func audioTicks(data []byte, duration time.Duration) uint64 {
+	var samples, sampleRate uint64
+
+	for len(data) > 0 {
+		h, err := radiko.ParseADTSHeader(data)
+
+		if err != nil || h.FrameLength > len(data) {
+			return uint64(duration * 90000 / time.Second)
+		}
+
+		// An AAC frame has 1024 samples.
+		samples += 1024
+		sampleRate = uint64(h.SampleRate())
+		data = data[h.FrameLength:]
+	}
+	if sampleRate == 0 {
+		return uint64(duration * 90000 / time.Second)
+	}
+
+	return samples * 90000 / sampleRate
+}
+
+// syncsafe encodes v as a 28-bit syncsafe integer used in ID3v2.
+func syncsafe(v int) []byte {
+	return []byte{byte(v>>21) & 0x7f, byte(v>>14) & 0x7f, byte(v>>7) & 0x7f, byte(v) & 0x7f}
+}
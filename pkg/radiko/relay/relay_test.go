@@ -0,0 +1,202 @@
+package relay
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+	"github.com/moutend/go-radiko/pkg/radiko/radikotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRelay(t *testing.T) (*radikotest.Server, *httptest.Server) {
+	upstream := radikotest.NewServer()
+
+	upstream.SegmentDuration = time.Second
+
+	relay := New(func(stationID string) *radiko.Client {
+		return radiko.New(stationID, "", "", radiko.WithBaseURL(upstream.URL), radiko.WithStreamBaseURL(upstream.URL))
+	})
+
+	server := httptest.NewServer(relay)
+
+	t.Cleanup(func() {
+		server.Close()
+		relay.Close()
+		upstream.Close()
+	})
+
+	return upstream, server
+}
+
+func TestLive(t *testing.T) {
+	upstream, server := newTestRelay(t)
+
+	var wg sync.WaitGroup
+
+	// The listeners share the upstream stream.
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			res, err := http.Get(server.URL + "/live/fmt")
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "audio/aac", res.Header.Get("Content-Type"))
+
+			header := make([]byte, 7)
+
+			_, err = io.ReadFull(res.Body, header)
+
+			assert.NoError(t, err)
+
+			_, err = radiko.ParseADTSHeader(header)
+
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, 1, upstream.Requests(radikotest.EndpointAuth1))
+
+	res, err := http.Get(server.URL + "/live/FMT/playlist.m3u8")
+
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	base, err := url.Parse(server.URL + "/live/FMT/playlist.m3u8")
+
+	require.NoError(t, err)
+
+	playlist, err := radiko.ParseM3U8(res.Body, base)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, playlist.Segments)
+	require.Equal(t, 1, upstream.Requests(radikotest.EndpointAuth1))
+
+	res, err = http.Get(playlist.Segments[0].URI)
+
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	data, err := io.ReadAll(res.Body)
+
+	require.NoError(t, err)
+
+	// The segment begins with the timestamp followed by the ADTS audio.
+	tag := timestampID3(0)
+
+	require.True(t, len(data) > len(tag))
+	require.Equal(t, tag[:10], data[:10])
+	require.Contains(t, string(data[:len(tag)]), "com.apple.streaming.transportStreamTimestamp")
+
+	_, err = radiko.ParseADTSHeader(data[len(tag):])
+
+	require.NoError(t, err)
+}
+
+func TestTimestampID3(t *testing.T) {
+	tag := timestampID3(1<<33 + 90000)
+
+	require.Equal(t, "ID3\x04\x00\x00\x00\x00\x00\x3f", string(tag[:10]))
+	require.Equal(t, "PRIV", string(tag[10:14]))
+	require.Equal(t, []byte{0, 0, 0, 0, 0, 1, 0x5f, 0x90}, tag[len(tag)-8:])
+
+	// The timestamp advances by the samples of the frames, 23 frames of 1024 samples at 24kHz here.
+	segment := radikotest.Segment(0, time.Second)[10:]
+
+	require.Equal(t, uint64(23*1024*90000/24000), audioTicks(segment, time.Second))
+	require.Equal(t, uint64(90000), audioTicks([]byte("broken"), time.Second))
+}
+
+func TestLiveError(t *testing.T) {
+	upstream, server := newTestRelay(t)
+
+	upstream.Fail(radikotest.EndpointLive, http.StatusForbidden, 0)
+
+	res, err := http.Get(server.URL + "/live/FMT")
+
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	// The failed channel is opened again.
+	upstream.Recover(radikotest.EndpointLive)
+
+	res, err = http.Get(server.URL + "/live/FMT/playlist.m3u8")
+
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestStationsAndGuide(t *testing.T) {
+	_, server := newTestRelay(t)
+
+	res, err := http.Get(server.URL + "/stations")
+
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	var stations []radiko.Station
+
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&stations))
+	require.NotEmpty(t, stations)
+
+	res, err = http.Get(server.URL + "/guide/FMT?date=2023-10-17")
+
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	var programs []radiko.Program
+
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&programs))
+	require.Len(t, programs, 24)
+	require.Equal(t, "FMT", programs[0].StationID)
+
+	res, err = http.Get(server.URL + "/guide/FMT?week=1")
+
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	programs = nil
+
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&programs))
+	require.Len(t, programs, 8*24)
+	require.True(t, radiko.BroadcastDate(time.Now()).Add(5*time.Hour).Equal(programs[0].Start))
+
+	res, err = http.Get(server.URL + "/guide/FMT?date=20231017")
+
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
@@ -0,0 +1,323 @@
+/*
+Package relay serves the live streams of radiko.jp over HTTP, so that devices which can't run the client are able to listen to them.
+
+The listeners of a station share one authenticated upstream stream, which is stopped when no one has listened to it for a while. Server provides the following endpoints.
+
+	GET /live/{stationID}                  continuous ADTS audio
+	GET /live/{stationID}/playlist.m3u8    HLS playlist of the recent segments
+	GET /live/{stationID}/{sequence}.aac   HLS segment
+	GET /stations                          list of all stations in JSON
+	GET /guide/{stationID}                 program guide of the day in JSON, or of the day specified by '?date=YYYY-MM-DD', or of the week with '?week=1'
+*/
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+)
+
+// Server relays the live streams of radiko.jp.
+type Server struct {
+	newClient func(stationID string) *radiko.Client
+	debug     *log.Logger
+
+	// Linger is how long the upstream stream is kept after the last listener leaves. The default is 30 seconds.
+	Linger time.Duration
+
+	// PlaylistSize is the number of segments in the HLS playlist. The default is 6.
+	PlaylistSize int
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	mu       sync.Mutex
+	channels map[string]*channel
+	mux      *http.ServeMux
+}
+
+// New returns a server. newClient is called to open the stream of a station and to fetch the stations and the program guide.
+func New(newClient func(stationID string) *radiko.Client) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &Server{
+		newClient:    newClient,
+		debug:        log.New(io.Discard, "", 0),
+		Linger:       30 * time.Second,
+		PlaylistSize: 6,
+		ctx:          ctx,
+		cancel:       cancel,
+		channels:     map[string]*channel{},
+		mux:          http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/live/", s.live)
+	s.mux.HandleFunc("/stations", s.stations)
+	s.mux.HandleFunc("/guide/", s.guide)
+
+	return s
+}
+
+// SetLogger sets a logger for printing debug messages.
+func (s *Server) SetLogger(logger *log.Logger) {
+	if logger == nil {
+		return
+	}
+
+	s.debug = logger
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close stops all upstream streams and disconnects the listeners.
+func (s *Server) Close() error {
+	s.cancel()
+
+	return nil
+}
+
+// channel returns the running channel of the station, or starts a new one.
+func (s *Server) channel(stationID string) (*channel, error) {
+	s.mu.Lock()
+
+	c, ok := s.channels[stationID]
+
+	if !ok || !c.alive() {
+		c = newChannel(stationID)
+		s.channels[stationID] = c
+
+		go s.open(c)
+	}
+
+	s.mu.Unlock()
+
+	<-c.ready
+
+	return c, c.openErr
+}
+
+func (s *Server) open(c *channel) {
+	ctx, cancel := context.WithCancel(s.ctx)
+
+	s.debug.Printf("relay: opening %s\n", c.stationID)
+
+	stream, err := s.newClient(c.stationID).OpenLiveStream(ctx)
+
+	if err != nil {
+		cancel()
+		c.openErr = err
+		c.close(err)
+		close(c.ready)
+		s.remove(c)
+
+		return
+	}
+
+	close(c.ready)
+
+	c.run(stream, s.PlaylistSize, func(c *channel) bool {
+		return c.idle(s.Linger)
+	})
+
+	cancel()
+	s.remove(c)
+
+	s.debug.Printf("relay: closed %s\n", c.stationID)
+}
+
+func (s *Server) remove(c *channel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.channels[c.stationID] == c {
+		delete(s.channels, c.stationID)
+	}
+}
+
+// live serves '/live/{stationID}', '/live/{stationID}/playlist.m3u8' and '/live/{stationID}/{sequence}.aac'.
+func (s *Server) live(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/live/"), "/")
+	stationID := strings.ToUpper(strings.TrimSuffix(path[0], ".aac"))
+
+	if stationID == "" || len(path) > 2 {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	c, err := s.channel(stationID)
+
+	if err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	if len(path) == 1 {
+		s.stream(w, r, c)
+
+		return
+	}
+	if path[1] == "playlist.m3u8" {
+		s.playlist(w, r, c)
+
+		return
+	}
+
+	sequence, err := strconv.Atoi(strings.TrimSuffix(path[1], ".aac"))
+
+	if err != nil {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	data, ok := c.segment(sequence)
+
+	if !ok {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/aac")
+	w.Write(data)
+}
+
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, c *channel) {
+	listener, ok := c.subscribe()
+
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+		return
+	}
+
+	defer c.unsubscribe(listener)
+
+	s.debug.Printf("relay: %s: listener connected from %s\n", c.stationID, r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "audio/aac")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data, ok := <-listener:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(data); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func (s *Server) playlist(w http.ResponseWriter, r *http.Request, c *channel) {
+	segments, err := c.playlist(r.Context())
+
+	if err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+
+	target := 0.0
+
+	for _, segment := range segments {
+		target = math.Max(target, segment.duration.Seconds())
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	fmt.Fprintf(w, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:%d\n", int(math.Ceil(target)), segments[0].sequence)
+
+	for _, segment := range segments {
+		fmt.Fprintf(w, "#EXTINF:%.3f,\n%d.aac\n", segment.duration.Seconds(), segment.sequence)
+	}
+}
+
+// stations serves '/stations'.
+func (s *Server) stations(w http.ResponseWriter, r *http.Request) {
+	client := s.newClient("")
+
+	if err := client.GetAllStations(r.Context()); err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, client.AllStations)
+}
+
+// guide serves '/guide/{stationID}'.
+func (s *Server) guide(w http.ResponseWriter, r *http.Request) {
+	stationID := strings.ToUpper(strings.TrimPrefix(r.URL.Path, "/guide/"))
+
+	if stationID == "" || strings.Contains(stationID, "/") {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	client := s.newClient(stationID)
+	query := r.URL.Query()
+
+	date, err := radiko.ParseBroadcastDate(query.Get("date"))
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid date: %v", err), http.StatusBadRequest)
+
+		return
+	}
+	if err := client.GetGuide(r.Context(), date, query.Get("week") != ""); err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, client.Programs)
+}
+
+// writeError responds the error of radiko.jp with a corresponding status code.
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	s.debug.Printf("relay: %v\n", err)
+
+	statusCode := http.StatusBadGateway
+
+	switch {
+	case errors.Is(err, radiko.ErrGeoRestricted), errors.Is(err, radiko.ErrStationNotInArea):
+		statusCode = http.StatusForbidden
+	case errors.Is(err, context.Canceled):
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	http.Error(w, err.Error(), statusCode)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,182 @@
+package radiko
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// recState is the state of a resumable recording saved next to the part file.
+type recState struct {
+	StationID string        `json:"station_id"`
+	Start     time.Time     `json:"start"`
+	Length    time.Duration `json:"length"`
+
+	// FirstSequence and LastSequence are the range of the segments in the part file.
+	FirstSequence int `json:"first_sequence"`
+	LastSequence  int `json:"last_sequence"`
+
+	// Size is the size of the part file when the last segment was completed.
+	Size int64 `json:"size"`
+}
+
+// same reports whether the state is of the same recording.
+func (s recState) same(other recState) bool {
+	return s.StationID == other.StationID && s.Start.Equal(other.Start) && s.Length == other.Length
+}
+
+func loadRecState(path string) (*recState, error) {
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("radiko: failed to read recording state: %w", err)
+	}
+
+	var state recState
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		// A broken state is discarded and the recording starts over.
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+func saveRecState(path string, state recState) error {
+	data, err := json.Marshal(state)
+
+	if err != nil {
+		return fmt.Errorf("radiko: failed to encode recording state: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".part-*")
+
+	if err != nil {
+		return fmt.Errorf("radiko: failed to create recording state: %w", err)
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+
+		return fmt.Errorf("radiko: failed to write recording state: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("radiko: failed to write recording state: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("radiko: failed to write recording state: %w", err)
+	}
+
+	return nil
+}
+
+// recordResumable appends the segments after the saved state to the part file, then muxes the part file into outputFile.
+func (c *Client) recordResumable(stream *Stream, state recState, outputFile string, config recConfig) error {
+	partFile := outputFile + ".part"
+	stateFile := outputFile + ".part.json"
+
+	state.FirstSequence = -1
+	state.LastSequence = -1
+
+	saved, err := loadRecState(stateFile)
+
+	if err != nil {
+		return err
+	}
+	if saved != nil && saved.same(state) {
+		// The part file may be shorter than the state after a crash, then the recording starts over.
+		if info, err := os.Stat(partFile); err == nil && info.Size() >= saved.Size {
+			state = *saved
+		}
+	}
+
+	part, err := os.OpenFile(partFile, os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return fmt.Errorf("radiko: failed to open part file: %w", err)
+	}
+
+	defer part.Close()
+
+	// Drop the incomplete segment written after the state was saved.
+	if err := part.Truncate(state.Size); err != nil {
+		return fmt.Errorf("radiko: failed to truncate part file: %w", err)
+	}
+	if _, err := part.Seek(state.Size, io.SeekStart); err != nil {
+		return fmt.Errorf("radiko: failed to seek part file: %w", err)
+	}
+	if state.LastSequence >= 0 {
+		c.debug.Printf("rec: resuming %s after segment %d (%d bytes)\n", outputFile, state.LastSequence, state.Size)
+
+		stream.skip(state.LastSequence)
+	}
+
+	r := config.reader(stream)
+
+	for {
+		data, segment, err := r.Next()
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("radiko: failed to record: %w", err)
+		}
+		if _, err := part.Write(data); err != nil {
+			return fmt.Errorf("radiko: failed to write part file: %w", err)
+		}
+		if state.FirstSequence < 0 {
+			state.FirstSequence = segment.Sequence
+		}
+
+		state.LastSequence = segment.Sequence
+		state.Size += int64(len(data))
+
+		if err := saveRecState(stateFile, state); err != nil {
+			return err
+		}
+	}
+	if err := part.Close(); err != nil {
+		return fmt.Errorf("radiko: failed to write part file: %w", err)
+	}
+
+	// The raw ADTS audio without tags is the part file itself.
+	if strings.EqualFold(filepath.Ext(outputFile), ".aac") && config.outputTags() == nil {
+		if err := os.Rename(partFile, outputFile); err != nil {
+			return fmt.Errorf("radiko: failed to rename part file: %w", err)
+		}
+
+		return os.Remove(stateFile)
+	}
+
+	input, err := os.Open(partFile)
+
+	if err != nil {
+		return fmt.Errorf("radiko: failed to open part file: %w", err)
+	}
+
+	defer input.Close()
+
+	if err := c.record(input, outputFile, config.outputTags()); err != nil {
+		return err
+	}
+
+	input.Close()
+
+	if err := os.Remove(partFile); err != nil {
+		return fmt.Errorf("radiko: failed to remove part file: %w", err)
+	}
+
+	return os.Remove(stateFile)
+}
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+)
+
+// JobKind represents how a job decides what to record.
+type JobKind string
+
+const (
+	// JobOnce records the window starting at Start once.
+	JobOnce JobKind = "once"
+
+	// JobWeekly records the window every week at the weekday and the time of Start in JST.
+	JobWeekly JobKind = "weekly"
+
+	// JobKeyword records every program whose title or performers contain Keyword.
+	JobKeyword JobKind = "keyword"
+)
+
+// Job represents a recording job.
+type Job struct {
+	ID        string        `json:"id"`
+	Kind      JobKind       `json:"kind"`
+	Name      string        `json:"name,omitempty"`
+	StationID string        `json:"station_id"`
+	Start     time.Time     `json:"start,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	Keyword   string        `json:"keyword,omitempty"`
+
+	// Live records the live stream on air instead of the timefree stream after the broadcast.
+	Live bool `json:"live,omitempty"`
+
+	// OutputDir is the directory of the recorded files. The default is the current directory.
+	OutputDir string `json:"output_dir,omitempty"`
+}
+
+// Validate reports whether the job has required fields.
+func (j Job) Validate() error {
+	if j.StationID == "" {
+		return fmt.Errorf("scheduler: station ID is required")
+	}
+
+	switch j.Kind {
+	case JobOnce, JobWeekly:
+		if j.Start.IsZero() || j.Duration <= 0 {
+			return fmt.Errorf("scheduler: start and duration are required for %s job", j.Kind)
+		}
+	case JobKeyword:
+		if j.Keyword == "" {
+			return fmt.Errorf("scheduler: keyword is required for keyword job")
+		}
+		if j.Live {
+			return fmt.Errorf("scheduler: keyword job can't record live stream")
+		}
+	default:
+		return fmt.Errorf("scheduler: unknown job kind: %q", j.Kind)
+	}
+
+	return nil
+}
+
+// Window represents a time range to record.
+type Window struct {
+	JobID     string    `json:"job_id"`
+	StationID string    `json:"station_id"`
+	Title     string    `json:"title"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Live      bool      `json:"live,omitempty"`
+	Output    string    `json:"output"`
+}
+
+// Key identifies the window among the windows of all jobs.
+func (w Window) Key() string {
+	return w.JobID + "/" + programKey(w.StationID, w.Start.Unix())
+}
+
+// windows returns the windows of the job which start between from and to.
+//
+// The programs are the weekly program guide of the station, which is used by keyword jobs.
+func (j Job) windows(from, to time.Time, programs radiko.ProgramSlice) []Window {
+	var windows []Window
+
+	switch j.Kind {
+	case JobOnce:
+		if !j.Start.Before(from) && j.Start.Before(to) {
+			windows = append(windows, j.window(j.Name, j.Start, j.Start.Add(j.Duration)))
+		}
+	case JobWeekly:
+		start := j.Start.In(radiko.JST)
+
+		// Find the first occurrence after from.
+		for start.Before(from) {
+			start = start.AddDate(0, 0, 7)
+		}
+		for ; start.Before(to); start = start.AddDate(0, 0, 7) {
+			windows = append(windows, j.window(j.Name, start, start.Add(j.Duration)))
+		}
+	case JobKeyword:
+		rule := Rule{Stations: []string{j.StationID}, Keywords: []string{j.Keyword}}
+
+		for _, p := range programs {
+			if p.Start.Before(from) || !p.Start.Before(to) {
+				continue
+			}
+			if rule.Match(p) {
+				windows = append(windows, j.window(p.Title, p.Start, p.End))
+			}
+		}
+	}
+
+	return windows
+}
+
+func (j Job) window(title string, start, end time.Time) Window {
+	if title == "" {
+		title = j.ID
+	}
+
+	program := radiko.Program{
+		StationID: j.StationID,
+		Start:     start,
+		Title:     title,
+	}
+
+	return Window{
+		JobID:     j.ID,
+		StationID: j.StationID,
+		Title:     title,
+		Start:     start,
+		End:       end,
+		Live:      j.Live,
+		Output:    filepath.Join(j.OutputDir, program.FileName(".m4a")),
+	}
+}
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+)
+
+// Rule matches programs in the program guide to record them automatically with the timefree stream.
+type Rule struct {
+	// Name identifies the rule in the history. It must be unique among the rules.
+	Name string `json:"name" mapstructure:"name"`
+
+	// Stations are the station IDs to watch.
+	Stations []string `json:"stations" mapstructure:"stations"`
+
+	// Keywords match programs whose title or performers contain any of them. The match is case-insensitive.
+	Keywords []string `json:"keywords" mapstructure:"keywords"`
+
+	// Exclude rejects programs whose title or performers contain any of them.
+	Exclude []string `json:"exclude,omitempty" mapstructure:"exclude"`
+
+	// OutputDir is the directory of the recorded files. The default is the current directory.
+	OutputDir string `json:"output_dir,omitempty" mapstructure:"output_dir"`
+}
+
+// Validate reports whether the rule has required fields.
+func (r Rule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("scheduler: rule name is required")
+	}
+	if len(r.Stations) == 0 {
+		return fmt.Errorf("scheduler: %s: stations are required", r.Name)
+	}
+	if len(r.Keywords) == 0 {
+		return fmt.Errorf("scheduler: %s: keywords are required", r.Name)
+	}
+
+	return nil
+}
+
+// Match reports whether the program is broadcast on one of the stations and matches the keywords.
+func (r Rule) Match(p radiko.Program) bool {
+	if !r.hasStation(p.StationID) {
+		return false
+	}
+
+	text := strings.ToLower(p.Title + "\n" + strings.Join(p.Performers, "\n"))
+
+	for _, keyword := range r.Exclude {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			return false
+		}
+	}
+	for _, keyword := range r.Keywords {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Rule) hasStation(stationID string) bool {
+	for _, s := range r.Stations {
+		if strings.EqualFold(s, stationID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RuleJobs returns a timefree job for each program matched by the rules.
+//
+// A program matched by several rules is recorded once by the first matching rule. The jobs are not stored; the same jobs are returned for the same program guide, so that the history of the recordings is kept across calls.
+func RuleJobs(rules []Rule, programs radiko.ProgramSlice) []Job {
+	var jobs []Job
+
+	seen := map[string]bool{}
+
+	for _, p := range programs {
+		key := programKey(p.StationID, p.Start.Unix())
+
+		if seen[key] {
+			continue
+		}
+		for _, r := range rules {
+			if !r.Match(p) {
+				continue
+			}
+
+			seen[key] = true
+
+			jobs = append(jobs, Job{
+				ID:        "rule:" + r.Name,
+				Kind:      JobOnce,
+				Name:      p.Title,
+				StationID: p.StationID,
+				Start:     p.Start,
+				Duration:  p.Duration(),
+				OutputDir: r.OutputDir,
+			})
+
+			break
+		}
+	}
+
+	return jobs
+}
+
+func programKey(stationID string, unix int64) string {
+	return fmt.Sprintf("%s/%d", stationID, unix)
+}
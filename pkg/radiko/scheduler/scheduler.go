@@ -0,0 +1,321 @@
+/*
+Package scheduler records radio programs at the right time based on a persistent list of jobs.
+
+A job records a window once, every week or whenever a program matching the keyword is broadcast. A Rule watches the weekly program guides of several stations and records every matching program. The windows in the past are recorded with the timefree stream, and the live windows are recorded while on air. Failed recordings are retried and every attempt is kept in the history of the store.
+*/
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+)
+
+// timefreeRetention is how long radiko.jp keeps the broadcast for timefree.
+const timefreeRetention = 7 * 24 * time.Hour
+
+type guide struct {
+	programs  radiko.ProgramSlice
+	fetchedAt time.Time
+}
+
+// Scheduler fires recordings of the jobs in the store.
+type Scheduler struct {
+	store     *Store
+	newClient func(stationID string) *radiko.Client
+	debug     *log.Logger
+
+	// Interval is how often the jobs are evaluated. The default is 30 seconds.
+	Interval time.Duration
+
+	// MaxAttempts is the number of attempts to record a window. The default is 3.
+	MaxAttempts int
+
+	// RetryDelay is how long to wait before retrying a failed recording. The default is 5 minutes.
+	RetryDelay time.Duration
+
+	// TimefreeDelay is how long to wait after the broadcast before recording it with the timefree stream. The default is 5 minutes.
+	TimefreeDelay time.Duration
+
+	// GuideTTL is how long the program guide is used for keyword jobs and rules. The default is 6 hours.
+	GuideTTL time.Duration
+
+	// Rules are evaluated on every tick in addition to the jobs in the store.
+	Rules []Rule
+
+	mu      sync.Mutex
+	running map[string]bool
+	guides  map[string]guide
+	wg      sync.WaitGroup
+}
+
+// New returns a scheduler. newClient is called for each recording and must return a client of the station.
+func New(store *Store, newClient func(stationID string) *radiko.Client) *Scheduler {
+	return &Scheduler{
+		store:         store,
+		newClient:     newClient,
+		debug:         log.New(io.Discard, "", 0),
+		Interval:      30 * time.Second,
+		MaxAttempts:   3,
+		RetryDelay:    5 * time.Minute,
+		TimefreeDelay: 5 * time.Minute,
+		GuideTTL:      6 * time.Hour,
+		running:       map[string]bool{},
+		guides:        map[string]guide{},
+	}
+}
+
+// SetLogger sets a logger for printing debug messages.
+func (s *Scheduler) SetLogger(logger *log.Logger) {
+	if logger == nil {
+		return
+	}
+
+	s.debug = logger
+}
+
+// Run evaluates the jobs every Interval until ctx is done. It waits for the running recordings before returning.
+//
+// A window starting before the next evaluation is evaluated again at its start, so that the live stream is recorded from the beginning.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		// The wall clock is read on every tick, so that the schedule follows the clock adjustment.
+		now := time.Now()
+		wait := s.Interval
+
+		if next := s.tick(ctx, now); !next.IsZero() && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.Wait()
+
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Tick evaluates the jobs at now and starts the recordings which are due.
+//
+// A program is recorded once even if it is covered by several jobs or rules. The jobs in the store take precedence over the rules.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) {
+	s.tick(ctx, now)
+}
+
+// tick is Tick which returns the start of the first window within the next Interval, or zero time if there is none.
+func (s *Scheduler) tick(ctx context.Context, now time.Time) time.Time {
+	jobs := s.store.Jobs()
+
+	if len(s.Rules) > 0 {
+		jobs = append(jobs, RuleJobs(s.Rules, s.rulePrograms(ctx, now))...)
+	}
+
+	var next time.Time
+
+	seen := map[string]bool{}
+
+	for _, job := range jobs {
+		var programs radiko.ProgramSlice
+
+		if job.Kind == JobKeyword {
+			programs = s.guide(ctx, job.StationID, now)
+		}
+		for _, w := range job.windows(now.Add(-timefreeRetention), now.Add(s.Interval), programs) {
+			if w.Start.After(now) {
+				if next.IsZero() || w.Start.Before(next) {
+					next = w.Start
+				}
+
+				continue
+			}
+
+			key := programKey(w.StationID, w.Start.Unix())
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			s.start(ctx, w, now)
+		}
+	}
+
+	return next
+}
+
+// Wait waits for the running recordings.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) guide(ctx context.Context, stationID string, now time.Time) radiko.ProgramSlice {
+	s.mu.Lock()
+	g, ok := s.guides[stationID]
+	s.mu.Unlock()
+
+	if ok && now.Sub(g.fetchedAt) < s.GuideTTL {
+		return g.programs
+	}
+
+	client := s.newClient(stationID)
+
+	if err := client.GetWeeklyPrograms(ctx); err != nil {
+		s.debug.Printf("scheduler: failed to fetch program guide of %s: %v\n", stationID, err)
+
+		// Keep using the stale guide until the next success.
+		return g.programs
+	}
+
+	s.mu.Lock()
+	s.guides[stationID] = guide{programs: client.Programs, fetchedAt: now}
+	s.mu.Unlock()
+
+	return client.Programs
+}
+
+// rulePrograms returns the weekly program guides of the stations watched by the rules.
+func (s *Scheduler) rulePrograms(ctx context.Context, now time.Time) radiko.ProgramSlice {
+	var programs radiko.ProgramSlice
+
+	seen := map[string]bool{}
+
+	for _, r := range s.Rules {
+		for _, stationID := range r.Stations {
+			stationID = strings.ToUpper(stationID)
+
+			if seen[stationID] {
+				continue
+			}
+
+			seen[stationID] = true
+			programs = append(programs, s.guide(ctx, stationID, now)...)
+		}
+	}
+
+	return programs
+}
+
+func (s *Scheduler) start(ctx context.Context, w Window, now time.Time) {
+	live := w.Live && now.Before(w.End)
+
+	if !live && now.Before(w.End.Add(s.TimefreeDelay)) {
+		return
+	}
+
+	key := w.Key()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running[key] {
+		return
+	}
+
+	failures := 0
+
+	for _, run := range s.store.runs(key) {
+		if run.Error == "" {
+			return
+		}
+
+		failures++
+
+		if now.Before(run.FinishedAt.Add(s.RetryDelay)) {
+			return
+		}
+	}
+	if failures >= s.MaxAttempts {
+		return
+	}
+
+	// The live stream can't be resumed, so a retry records the rest to a new part instead of overwriting the audio recorded so far.
+	if live && failures > 0 {
+		w.Output = partPath(w.Output, failures+1)
+	}
+
+	s.running[key] = true
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		run := Run{Window: w, StartedAt: time.Now()}
+
+		if err := s.record(ctx, w, live); err != nil {
+			run.Error = err.Error()
+
+			s.debug.Printf("scheduler: failed to record %s: %v\n", key, err)
+		}
+
+		run.FinishedAt = time.Now()
+
+		if err := s.store.AddRun(run); err != nil {
+			s.debug.Printf("scheduler: failed to save history: %v\n", err)
+		}
+
+		s.mu.Lock()
+		delete(s.running, key)
+		s.mu.Unlock()
+	}()
+}
+
+func (s *Scheduler) record(ctx context.Context, w Window, live bool) error {
+	if dir := filepath.Dir(w.Output); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("scheduler: failed to create directory: %w", err)
+		}
+	}
+
+	client := s.newClient(w.StationID)
+	program := s.program(ctx, w)
+	tags := radiko.WithTags(client.ProgramTags(ctx, program))
+	sidecar := radiko.WithSidecar(program)
+
+	if live {
+		s.debug.Printf("scheduler: recording live %s to %s\n", w.Key(), w.Output)
+
+		return client.RecLive(ctx, time.Until(w.End), w.Output, tags, sidecar)
+	}
+
+	s.debug.Printf("scheduler: recording timefree %s to %s\n", w.Key(), w.Output)
+
+	// A retry continues from the segments downloaded by the failed attempt.
+	return client.Rec(ctx, w.Start, w.End.Sub(w.Start), w.Output, radiko.WithResume(), tags, sidecar)
+}
+
+// program returns the program of the window from the weekly program guide, so that the recording is tagged and described by the sidecar like 'radiko rec'.
+//
+// The window which doesn't start at a program in the guide, e.g. a once job starting at an arbitrary time, is described by itself.
+func (s *Scheduler) program(ctx context.Context, w Window) radiko.Program {
+	for _, p := range s.guide(ctx, w.StationID, time.Now()) {
+		if p.Start.Equal(w.Start) {
+			// The window may be shorter or longer than the program.
+			p.End = w.End
+
+			return p
+		}
+	}
+
+	return radiko.Program{StationID: w.StationID, Start: w.Start, End: w.End, Title: w.Title}
+}
+
+// partPath returns the path of the n-th part of the recording, e.g. 'a.part2.m4a' for 'a.m4a'.
+func partPath(path string, n int) string {
+	ext := filepath.Ext(path)
+
+	return fmt.Sprintf("%s.part%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
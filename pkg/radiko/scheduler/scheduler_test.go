@@ -0,0 +1,311 @@
+package scheduler
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko"
+	"github.com/moutend/go-radiko/pkg/radiko/radikotest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScheduler(t *testing.T, server *radikotest.Server) *Scheduler {
+	store, err := Open(filepath.Join(t.TempDir(), "jobs.json"))
+
+	require.NoError(t, err)
+
+	s := New(store, func(stationID string) *radiko.Client {
+		return radiko.New(stationID, "", "", radiko.WithBaseURL(server.URL), radiko.WithStreamBaseURL(server.URL))
+	})
+
+	s.RetryDelay = 0
+
+	return s
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	store, err := Open(path)
+
+	require.NoError(t, err)
+
+	_, err = store.Add(Job{Kind: JobOnce, StationID: "FMT"})
+
+	require.Error(t, err)
+
+	job, err := store.Add(Job{Kind: JobKeyword, StationID: "FMT", Keyword: "news"})
+
+	require.NoError(t, err)
+	require.NotEmpty(t, job.ID)
+
+	store, err = Open(path)
+
+	require.NoError(t, err)
+	require.Equal(t, []Job{job}, store.Jobs())
+	require.NoError(t, store.Remove(job.ID))
+	require.Error(t, store.Remove(job.ID))
+	require.Empty(t, store.Jobs())
+}
+
+func TestStoreSharedWithAnotherProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	daemon, err := Open(path)
+
+	require.NoError(t, err)
+
+	cli, err := Open(path)
+
+	require.NoError(t, err)
+
+	job, err := cli.Add(Job{Kind: JobKeyword, StationID: "FMT", Keyword: "news"})
+
+	require.NoError(t, err)
+	require.Equal(t, []Job{job}, daemon.Jobs())
+
+	// Saving the history must not write back the jobs known by the daemon.
+	require.NoError(t, daemon.AddRun(Run{Window: Window{JobID: job.ID}}))
+	require.NoError(t, cli.Remove(job.ID))
+	require.NoError(t, daemon.AddRun(Run{Window: Window{JobID: job.ID}}))
+	require.Empty(t, daemon.Jobs())
+
+	reopened, err := Open(path)
+
+	require.NoError(t, err)
+	require.Empty(t, reopened.Jobs())
+	require.Len(t, reopened.History(), 2)
+}
+
+func TestJobWindows(t *testing.T) {
+	job := Job{
+		ID:        "weekly",
+		Kind:      JobWeekly,
+		StationID: "FMT",
+		Start:     time.Date(2023, 10, 3, 21, 0, 0, 0, radiko.JST),
+		Duration:  30 * time.Minute,
+		OutputDir: "out",
+	}
+
+	windows := job.windows(time.Date(2023, 10, 10, 0, 0, 0, 0, radiko.JST), time.Date(2023, 10, 24, 0, 0, 0, 0, radiko.JST), nil)
+
+	require.Len(t, windows, 2)
+	require.Equal(t, time.Date(2023, 10, 10, 21, 0, 0, 0, radiko.JST), windows[0].Start)
+	require.Equal(t, time.Date(2023, 10, 17, 21, 30, 0, 0, radiko.JST), windows[1].End)
+	require.Equal(t, filepath.Join("out", "FMT_20231010-2100_weekly.m4a"), windows[0].Output)
+}
+
+func TestSchedulerOnce(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	s := newTestScheduler(t, server)
+	output := t.TempDir()
+
+	_, err := s.store.Add(Job{
+		Kind:      JobOnce,
+		Name:      "Test",
+		StationID: "FMT",
+		Start:     time.Now().Add(-time.Hour).Truncate(time.Minute),
+		Duration:  10 * time.Second,
+		OutputDir: output,
+	})
+
+	require.NoError(t, err)
+
+	server.Fail(radikotest.EndpointTimefree, http.StatusInternalServerError, 1)
+
+	// The first attempt fails and the second one succeeds.
+	for i := 0; i < 3; i++ {
+		s.Tick(context.Background(), time.Now())
+		s.Wait()
+	}
+
+	history := s.store.History()
+
+	require.Len(t, history, 2)
+	require.NotEmpty(t, history[0].Error)
+	require.Empty(t, history[1].Error)
+
+	_, err = os.Stat(history[1].Window.Output)
+
+	require.NoError(t, err)
+}
+
+func TestSchedulerLiveRetry(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.SegmentDuration = time.Second
+
+	s := newTestScheduler(t, server)
+
+	_, err := s.store.Add(Job{
+		Kind:      JobOnce,
+		Name:      "Live",
+		StationID: "FMT",
+		Start:     time.Now().Add(-time.Second),
+		Duration:  3 * time.Second,
+		Live:      true,
+		OutputDir: t.TempDir(),
+	})
+
+	require.NoError(t, err)
+
+	server.Fail(radikotest.EndpointAuth1, http.StatusInternalServerError, 1)
+
+	for i := 0; i < 2; i++ {
+		s.Tick(context.Background(), time.Now())
+		s.Wait()
+	}
+
+	history := s.store.History()
+
+	require.Len(t, history, 2)
+	require.NotEmpty(t, history[0].Error)
+	require.Empty(t, history[1].Error)
+
+	// The retry is recorded to a new part, so that the audio recorded by the first attempt is kept.
+	require.Equal(t, partPath(history[0].Window.Output, 2), history[1].Window.Output)
+
+	_, err = os.Stat(history[1].Window.Output)
+
+	require.NoError(t, err)
+}
+
+func TestSchedulerWaitsForNextWindow(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	s := newTestScheduler(t, server)
+	now := time.Date(2023, 10, 17, 20, 59, 50, 0, radiko.JST)
+
+	_, err := s.store.Add(Job{Kind: JobOnce, StationID: "FMT", Start: now.Add(10 * time.Second), Duration: time.Hour, Live: true})
+
+	require.NoError(t, err)
+
+	// The live window starting before the next tick is reported to wake up at its start.
+	require.True(t, now.Add(10*time.Second).Equal(s.tick(context.Background(), now)))
+	require.True(t, s.tick(context.Background(), now.Add(-time.Minute)).IsZero())
+
+	s.Wait()
+
+	require.Empty(t, s.store.History())
+}
+
+func TestSchedulerKeyword(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Minute)
+
+	server.Programs = []radikotest.Program{
+		{ID: "1", StationID: "FMT", Start: start, End: start.Add(10 * time.Second), Title: "Morning News"},
+		{ID: "2", StationID: "FMT", Start: start.Add(10 * time.Second), End: start.Add(20 * time.Second), Title: "Music", Performer: "Jane Doe"},
+		{ID: "3", StationID: "FMT", Start: start.Add(20 * time.Second), End: start.Add(30 * time.Second), Title: "Sports"},
+	}
+
+	s := newTestScheduler(t, server)
+
+	_, err := s.store.Add(Job{
+		Kind:      JobKeyword,
+		StationID: "FMT",
+		Keyword:   "jane",
+		OutputDir: t.TempDir(),
+	})
+
+	require.NoError(t, err)
+
+	s.Tick(context.Background(), time.Now())
+	s.Wait()
+
+	history := s.store.History()
+
+	require.Len(t, history, 1)
+	require.Equal(t, "Music", history[0].Window.Title)
+	require.Empty(t, history[0].Error)
+
+	// The recording is tagged with the program in the guide, including its artwork.
+	require.Equal(t, 1, server.Requests(radikotest.EndpointImage))
+
+	recording, err := radiko.ReadRecording(history[0].Window.Output)
+
+	require.NoError(t, err)
+	require.Equal(t, "2", recording.Program.ID)
+	require.Equal(t, []string{"Jane Doe"}, recording.Program.Performers)
+}
+
+func TestRuleJobs(t *testing.T) {
+	start := time.Date(2023, 10, 17, 21, 0, 0, 0, radiko.JST)
+
+	programs := radiko.ProgramSlice{
+		{StationID: "TBS", Start: start, End: start.Add(time.Hour), Title: "Baseball Night"},
+		{StationID: "QRR", Start: start, End: start.Add(time.Hour), Title: "Talk", Performers: []string{"Jane Doe"}},
+		{StationID: "QRR", Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), Title: "Baseball Replay"},
+		{StationID: "FMT", Start: start, End: start.Add(time.Hour), Title: "Baseball Music"},
+	}
+	rules := []Rule{
+		{Name: "baseball", Stations: []string{"tbs", "QRR"}, Keywords: []string{"BASEBALL"}, Exclude: []string{"replay"}},
+		{Name: "jane", Stations: []string{"TBS", "QRR"}, Keywords: []string{"jane", "baseball"}},
+	}
+
+	require.NoError(t, rules[0].Validate())
+	require.Error(t, Rule{Name: "empty"}.Validate())
+
+	jobs := RuleJobs(rules, programs)
+
+	require.Len(t, jobs, 3)
+	require.Equal(t, "rule:baseball", jobs[0].ID)
+	require.Equal(t, "Baseball Night", jobs[0].Name)
+	require.Equal(t, time.Hour, jobs[0].Duration)
+	require.Equal(t, "rule:jane", jobs[1].ID)
+	require.Equal(t, "QRR", jobs[1].StationID)
+	require.Equal(t, "rule:jane", jobs[2].ID)
+	require.Equal(t, "Baseball Replay", jobs[2].Name)
+}
+
+func TestSchedulerRules(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Minute)
+
+	server.Programs = []radikotest.Program{
+		{ID: "1", StationID: "FMT", Start: start, End: start.Add(10 * time.Second), Title: "Morning News"},
+		{ID: "2", StationID: "FMT", Start: start.Add(10 * time.Second), End: start.Add(20 * time.Second), Title: "Music", Performer: "Jane Doe"},
+	}
+
+	s := newTestScheduler(t, server)
+	output := t.TempDir()
+
+	s.Rules = []Rule{
+		{Name: "jane", Stations: []string{"FMT"}, Keywords: []string{"jane"}, OutputDir: output},
+		{Name: "music", Stations: []string{"FMT"}, Keywords: []string{"music"}, OutputDir: output},
+	}
+
+	// The keyword job takes precedence over the rules.
+	_, err := s.store.Add(Job{Kind: JobKeyword, StationID: "FMT", Keyword: "music", OutputDir: output})
+
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		s.Tick(context.Background(), time.Now())
+		s.Wait()
+	}
+
+	history := s.store.History()
+
+	require.Len(t, history, 1)
+	require.Equal(t, "Music", history[0].Window.Title)
+	require.NotContains(t, history[0].Window.JobID, "rule:")
+	require.Empty(t, history[0].Error)
+}
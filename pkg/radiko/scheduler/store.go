@@ -0,0 +1,223 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Run represents an attempt to record a window.
+type Run struct {
+	Window     Window    `json:"window"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Store persists jobs and their history as JSON files.
+//
+// The jobs are read from the file on every access, so that the jobs added or removed by another process are picked up by the running scheduler. The history is kept in a separate file next to the jobs, which is written by the scheduler only.
+type Store struct {
+	path        string
+	historyPath string
+
+	mu      sync.Mutex
+	jobs    []Job
+	history []Run
+}
+
+// Open opens the store located at path. The file is created when the first job is added.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:        path,
+		historyPath: strings.TrimSuffix(path, filepath.Ext(path)) + "-history.json",
+	}
+
+	if err := readJSON(s.historyPath, &s.history); err != nil {
+		return nil, fmt.Errorf("scheduler: failed to read history: %w", err)
+	}
+	jobs, err := s.readJobs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.jobs = jobs
+
+	return s, nil
+}
+
+// Jobs returns all jobs.
+func (s *Store) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep the jobs read last time while the file is broken, e.g. being edited by hand.
+	if jobs, err := s.readJobs(); err == nil {
+		s.jobs = jobs
+	}
+
+	return append([]Job(nil), s.jobs...)
+}
+
+// Add adds the job and returns it with a new ID.
+func (s *Store) Add(job Job) (Job, error) {
+	if err := job.Validate(); err != nil {
+		return Job{}, err
+	}
+
+	id := make([]byte, 4)
+
+	if _, err := rand.Read(id); err != nil {
+		return Job{}, fmt.Errorf("scheduler: failed to generate job ID: %w", err)
+	}
+
+	job.ID = hex.EncodeToString(id)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.readJobs()
+
+	if err != nil {
+		return Job{}, err
+	}
+
+	return job, s.writeJobs(append(jobs, job))
+}
+
+// Remove removes the job.
+func (s *Store) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.readJobs()
+
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		if jobs[i].ID == id {
+			return s.writeJobs(append(jobs[:i], jobs[i+1:]...))
+		}
+	}
+
+	return fmt.Errorf("scheduler: job not found: %q", id)
+}
+
+// History returns all runs.
+func (s *Store) History() []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Run(nil), s.history...)
+}
+
+// AddRun appends the run to the history.
+func (s *Store) AddRun(run Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = append(s.history, run)
+
+	if err := writeJSON(s.historyPath, s.history); err != nil {
+		return fmt.Errorf("scheduler: failed to write history: %w", err)
+	}
+
+	return nil
+}
+
+// runs returns the runs of the window.
+func (s *Store) runs(key string) []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var runs []Run
+
+	for _, run := range s.history {
+		if run.Window.Key() == key {
+			runs = append(runs, run)
+		}
+	}
+
+	return runs
+}
+
+func (s *Store) readJobs() ([]Job, error) {
+	var data struct {
+		Jobs []Job `json:"jobs"`
+	}
+
+	if err := readJSON(s.path, &data); err != nil {
+		return nil, fmt.Errorf("scheduler: failed to read store: %w", err)
+	}
+
+	return data.Jobs, nil
+}
+
+func (s *Store) writeJobs(jobs []Job) error {
+	data := struct {
+		Jobs []Job `json:"jobs"`
+	}{jobs}
+
+	if err := writeJSON(s.path, data); err != nil {
+		return fmt.Errorf("scheduler: failed to write store: %w", err)
+	}
+
+	s.jobs = jobs
+
+	return nil
+}
+
+// readJSON decodes the file into v. A missing file is not an error and leaves v untouched.
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that the file is never left broken.
+	file, err := os.CreateTemp(filepath.Dir(path), ".jobs-*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,35 @@
+package radiko
+
+import (
+	"time"
+)
+
+// sessionLifetime is how long an auth token is expected to be valid.
+//
+// radiko.jp doesn't tell the expiry, so this is a conservative estimate.
+const sessionLifetime = time.Hour
+
+// MemberType represents the membership of radiko.jp.
+type MemberType string
+
+const (
+	MemberTypeNormal  MemberType = "normal"
+	MemberTypePremium MemberType = "premium"
+)
+
+// Session holds the state of an authenticated session.
+//
+// A session can be shared by clients of different stations.
+type Session struct {
+	AuthToken     string     `json:"auth_token"`
+	AreaName      string     `json:"area_name"`
+	AExp          string     `json:"a_exp"`
+	RadikoSession string     `json:"radiko_session"`
+	MemberType    MemberType `json:"member_type"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+}
+
+// Expired reports whether the session is expired at t.
+func (s *Session) Expired(t time.Time) bool {
+	return !t.Before(s.ExpiresAt)
+}
@@ -0,0 +1,142 @@
+package radiko
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// AudioSink receives the live stream as ADTS audio. See PlayTo.
+type AudioSink interface {
+	// Start starts the sink and returns the writer of the audio. The writer is closed when the stream ends.
+	Start(ctx context.Context) (io.WriteCloser, error)
+
+	// Wait waits until the sink finishes, e.g. the player exits or the writer is closed.
+	Wait() error
+}
+
+// FFplaySink returns a sink playing the audio with ffplay command.
+func FFplaySink(volume int) AudioSink {
+	return &commandSink{
+		name: "ffplay",
+		args: []string{"-nodisp", "-loglevel", "quiet", "-volume", fmt.Sprint(volume), "-f", "aac", "-i", "-"},
+	}
+}
+
+// MPVSink returns a sink playing the audio with mpv command.
+func MPVSink(volume int) AudioSink {
+	return &commandSink{
+		name: "mpv",
+		args: []string{"--no-video", "--really-quiet", fmt.Sprintf("--volume=%d", volume), "-"},
+	}
+}
+
+// PCMSink returns a sink decoding the audio with ffmpeg command and writing it to w as 16-bit little-endian stereo PCM at 48kHz.
+func PCMSink(w io.Writer) AudioSink {
+	return &commandSink{
+		name:   "ffmpeg",
+		args:   []string{"-loglevel", "error", "-f", "aac", "-i", "-", "-f", "s16le", "-ac", "2", "-ar", "48000", "-"},
+		stdout: w,
+	}
+}
+
+type commandSink struct {
+	name   string
+	args   []string
+	stdout io.Writer
+	cmd    *exec.Cmd
+}
+
+func (s *commandSink) Start(ctx context.Context) (io.WriteCloser, error) {
+	s.cmd = exec.CommandContext(ctx, s.name, s.args...)
+	s.cmd.Stdout = s.stdout
+
+	stdin, err := s.cmd.StdinPipe()
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to create pipe: %w", s.name, err)
+	}
+	if err := s.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%s: failed to start command: %w", s.name, err)
+	}
+
+	return stdin, nil
+}
+
+func (s *commandSink) Wait() error {
+	if err := s.cmd.Wait(); err != nil {
+		return fmt.Errorf("%s: unexpected error: %w", s.name, err)
+	}
+
+	return nil
+}
+
+// WriterSink returns a sink writing the ADTS audio to w as is, e.g. to the standard output or to a buffer of your program.
+func WriterSink(w io.Writer) AudioSink {
+	return &writerSink{open: func() (io.Writer, func() error, error) {
+		return w, nil, nil
+	}}
+}
+
+// FileSink returns a sink writing the ADTS audio to the file. The file is truncated if it exists.
+func FileSink(path string) AudioSink {
+	return &writerSink{open: func() (io.Writer, func() error, error) {
+		file, err := os.Create(path)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("file: %w", err)
+		}
+
+		return file, file.Close, nil
+	}}
+}
+
+type writerSink struct {
+	open func() (io.Writer, func() error, error)
+	done chan error
+}
+
+func (s *writerSink) Start(ctx context.Context) (io.WriteCloser, error) {
+	w, closer, err := s.open()
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.done = make(chan error, 1)
+
+	return &sinkWriter{w: w, closer: closer, done: s.done}, nil
+}
+
+func (s *writerSink) Wait() error {
+	return <-s.done
+}
+
+// sinkWriter notifies the sink when it is closed.
+type sinkWriter struct {
+	w      io.Writer
+	closer func() error
+	done   chan<- error
+	once   sync.Once
+}
+
+func (w *sinkWriter) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+func (w *sinkWriter) Close() error {
+	var err error
+
+	w.once.Do(func() {
+		if w.closer != nil {
+			err = w.closer()
+		}
+
+		w.done <- err
+	})
+
+	return err
+}
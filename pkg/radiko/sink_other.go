@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package radiko
+
+import (
+	"errors"
+	"io"
+)
+
+// NamedPipeSink returns a sink writing the ADTS audio to the named pipe. It is not supported on this platform.
+func NamedPipeSink(path string) AudioSink {
+	return &writerSink{open: func() (io.Writer, func() error, error) {
+		return nil, nil, errors.New("named pipe: not supported on this platform")
+	}}
+}
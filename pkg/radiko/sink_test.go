@@ -0,0 +1,154 @@
+package radiko
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko/radikotest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayToWriter(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.SegmentDuration = time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+
+	defer cancel()
+
+	buf := &bytes.Buffer{}
+	err := newTestClient(server, "FMT", "", "").PlayTo(ctx, WriterSink(buf))
+
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.True(t, buf.Len() > 0)
+
+	_, err = ParseADTSHeader(buf.Bytes())
+
+	require.NoError(t, err)
+}
+
+func TestPlayToFile(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.SegmentDuration = time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+
+	defer cancel()
+
+	output := filepath.Join(t.TempDir(), "output.aac")
+	err := newTestClient(server, "FMT", "", "").PlayTo(ctx, FileSink(output))
+
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	data, err := os.ReadFile(output)
+
+	require.NoError(t, err)
+
+	_, err = ParseADTSHeader(data)
+
+	require.NoError(t, err)
+}
+
+func TestPlayToNamedPipe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipe is not supported")
+	}
+
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.SegmentDuration = time.Second
+
+	pipe := filepath.Join(t.TempDir(), "radiko.fifo")
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- newTestClient(server, "FMT", "", "").PlayTo(context.Background(), NamedPipeSink(pipe))
+	}()
+
+	var (
+		file *os.File
+		err  error
+	)
+
+	// Wait for the pipe created by the sink.
+	for i := 0; i < 100; i++ {
+		if file, err = os.Open(pipe); err == nil {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.NoError(t, err)
+
+	header := make([]byte, 7)
+
+	_, err = io.ReadFull(file, header)
+
+	require.NoError(t, err)
+
+	_, err = ParseADTSHeader(header)
+
+	require.NoError(t, err)
+
+	// The playback ends when the reader closes the pipe.
+	require.NoError(t, file.Close())
+	require.NoError(t, <-errCh)
+}
+
+func TestPlayToCommand(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat command is not found")
+	}
+
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.SegmentDuration = time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+
+	defer cancel()
+
+	buf := &bytes.Buffer{}
+	err := newTestClient(server, "FMT", "", "").PlayTo(ctx, &commandSink{name: "cat", stdout: buf})
+
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.True(t, buf.Len() > 0)
+}
+
+func TestPlayToStreamError(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	server.SegmentDuration = time.Second
+	server.Fail(radikotest.EndpointSegment, http.StatusInternalServerError, 0)
+
+	client := New("FMT", "", "", WithBaseURL(server.URL), WithStreamBaseURL(server.URL), WithReconnectPolicy(ReconnectPolicy{}))
+
+	err := client.PlayTo(context.Background(), WriterSink(io.Discard))
+
+	var statusErr *HTTPStatusError
+
+	require.True(t, errors.As(err, &statusErr))
+	require.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+}
@@ -0,0 +1,32 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package radiko
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// NamedPipeSink returns a sink writing the ADTS audio to the named pipe, which is created if it doesn't exist.
+//
+// Starting the sink blocks until a reader opens the pipe.
+func NamedPipeSink(path string) AudioSink {
+	return &writerSink{open: func() (io.Writer, func() error, error) {
+		if err := unix.Mkfifo(path, 0o600); err != nil && !errors.Is(err, fs.ErrExist) {
+			return nil, nil, fmt.Errorf("named pipe: %w", err)
+		}
+
+		pipe, err := os.OpenFile(path, os.O_WRONLY, 0)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("named pipe: %w", err)
+		}
+
+		return pipe, pipe.Close, nil
+	}}
+}
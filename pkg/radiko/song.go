@@ -0,0 +1,65 @@
+package radiko
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type NoaXML struct {
+	Items []NoaItem `xml:"item"`
+}
+
+type NoaItem struct {
+	Stamp        string `xml:"stamp,attr"`
+	Title        string `xml:"title,attr"`
+	Artist       string `xml:"artist,attr"`
+	ProgramTitle string `xml:"program_title,attr"`
+	Image        string `xml:"img,attr"`
+	ImageLarge   string `xml:"img_large,attr"`
+}
+
+// Song represents a song played on a station.
+type Song struct {
+	Stamp        time.Time `json:"stamp"`
+	Title        string    `json:"title"`
+	Artist       string    `json:"artist"`
+	ProgramTitle string    `json:"program_title"`
+	Image        string    `json:"image"`
+}
+
+// ParseNoaXML parses the on-air music history feed.
+func ParseNoaXML(r io.Reader) ([]Song, error) {
+	var v NoaXML
+
+	if err := xml.NewDecoder(r).Decode(&v); err != nil {
+		return nil, fmt.Errorf("radiko: failed to parse XML: %w", err)
+	}
+
+	var songs []Song
+
+	for _, item := range v.Items {
+		stamp, err := time.ParseInLocation("2006-01-02 15:04:05", item.Stamp, JST)
+
+		if err != nil {
+			return nil, fmt.Errorf("radiko: failed to parse timestamp: %w", err)
+		}
+
+		image := item.ImageLarge
+
+		if image == "" {
+			image = item.Image
+		}
+
+		songs = append(songs, Song{
+			Stamp:        stamp,
+			Title:        item.Title,
+			Artist:       item.Artist,
+			ProgramTitle: item.ProgramTitle,
+			Image:        image,
+		})
+	}
+
+	return songs, nil
+}
@@ -0,0 +1,32 @@
+package radiko
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNoaXML(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "Noa.xml"))
+
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	songs, err := ParseNoaXML(file)
+
+	require.NoError(t, err)
+	require.Len(t, songs, 3)
+
+	for i := range songs {
+		require.NotEmpty(t, songs[i].Title)
+		require.NotEmpty(t, songs[i].Artist)
+	}
+
+	require.Equal(t, time.Date(2023, 10, 16, 23, 52, 10, 0, time.UTC), songs[0].Stamp.UTC())
+	require.Equal(t, "https://example.com/jacket/1_large.jpg", songs[0].Image)
+	require.Equal(t, "https://example.com/jacket/2.jpg", songs[1].Image)
+}
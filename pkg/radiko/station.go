@@ -1,11 +1,22 @@
 package radiko
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
 )
 
+// maxLogoSize limits the size of the logo downloaded by GetStationLogo.
+const maxLogoSize = 1 << 20
+
 type FullStationXML struct {
 	Stations []Stations `xml:"stations"`
 }
@@ -17,6 +28,13 @@ type Stations struct {
 	Station    []Station `xml:"station"`
 }
 
+// AreaStationXML is the station list of an area.
+type AreaStationXML struct {
+	AreaID   string    `xml:"area_id,attr"`
+	AreaName string    `xml:"area_name,attr"`
+	Station  []Station `xml:"station"`
+}
+
 type Station struct {
 	ID        string `json:"id" xml:"id"`
 	Name      string `json:"name" xml:"name"`
@@ -24,6 +42,101 @@ type Station struct {
 	Ruby      string `json:"ruby" xml:"ruby"`
 	Areafree  int    `json:"areafree" xml:"areafree"`
 	Timefree  int    `json:"timefree" xml:"timefree"`
+
+	// AreaID is the area such as 'JP13' where the station broadcasts.
+	AreaID string `json:"area_id" xml:"area_id"`
+
+	// RegionID is the region such as 'kanto'. It is set only by ParseRegionXML and ParseFullStationXML.
+	RegionID string `json:"region_id,omitempty" xml:"-"`
+
+	// Logos are the logo images in various sizes.
+	Logos []Logo `json:"logos,omitempty" xml:"logo"`
+
+	// Banner is the URL of the banner image.
+	Banner string `json:"banner,omitempty" xml:"banner"`
+
+	// Href is the URL of the station's website.
+	Href string `json:"href,omitempty" xml:"href"`
+
+	// TimefreeMaxDelay is the delay in seconds until a program becomes available on timefree.
+	TimefreeMaxDelay int `json:"tf_max_delay,omitempty" xml:"tf_max_delay"`
+}
+
+// Logo is a logo image of the station.
+type Logo struct {
+	Width  int    `json:"width" xml:"width,attr"`
+	Height int    `json:"height" xml:"height,attr"`
+	URL    string `json:"url" xml:",chardata"`
+
+	// Align is 'center' for the logo with margins, or 'lrtrim' for the logo without left and right margins.
+	Align string `json:"align" xml:"align,attr"`
+}
+
+// Logo returns the logo of the size. If there is no such logo, the smallest logo larger than the size is returned, and then the largest one.
+//
+// The logo with margins is preferred to the one without them.
+func (s Station) Logo(width, height int) (Logo, bool) {
+	var found, largest Logo
+
+	for _, logo := range s.Logos {
+		if logo.Width == width && logo.Height == height && (logo.Align == "center" || found.URL == "") {
+			found = logo
+		}
+		if largest.URL == "" || logo.Width*logo.Height > largest.Width*largest.Height {
+			largest = logo
+		}
+	}
+	if found.URL != "" {
+		return found, true
+	}
+	for _, logo := range s.Logos {
+		if logo.Width < width || logo.Height < height {
+			continue
+		}
+		if found.URL == "" || logo.Width*logo.Height < found.Width*found.Height {
+			found = logo
+		}
+	}
+	if found.URL != "" {
+		return found, true
+	}
+
+	return largest, largest.URL != ""
+}
+
+// GetStationLogo downloads the logo of the station in PNG format. See Station.Logo for how the logo is chosen by the size.
+func (c *Client) GetStationLogo(ctx context.Context, station Station, width, height int) ([]byte, error) {
+	logo, ok := station.Logo(width, height)
+
+	if !ok {
+		return nil, fmt.Errorf("logo: %s has no logo", station.ID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, logo.URL, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("logo: failed to create request: %w", err)
+	}
+
+	res, err := c.do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("logo: error response: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("logo: %w", &HTTPStatusError{Step: "logo", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxLogoSize))
+
+	if err != nil {
+		return nil, fmt.Errorf("logo: failed to read response body: %w", err)
+	}
+
+	return data, nil
 }
 
 type StationSlice []Station
@@ -38,20 +151,245 @@ func (s StationSlice) Match(fn func(Station) bool) bool {
 	return false
 }
 
+// Find returns the station with the ID. The ID is case-insensitive.
+func (s StationSlice) Find(id string) (Station, bool) {
+	for i := range s {
+		if strings.EqualFold(s[i].ID, id) {
+			return s[i], true
+		}
+	}
+
+	return Station{}, false
+}
+
+// Filter returns the stations for which fn returns true.
+func (s StationSlice) Filter(fn func(Station) bool) StationSlice {
+	var stations StationSlice
+
+	for i := range s {
+		if fn(s[i]) {
+			stations = append(stations, s[i])
+		}
+	}
+
+	return stations
+}
+
+// Areafree returns the stations playable outside their area by the premium member.
+func (s StationSlice) Areafree() StationSlice {
+	return s.Filter(func(station Station) bool {
+		return station.Areafree == 1
+	})
+}
+
+// Timefree returns the stations whose programs are available on timefree.
+func (s StationSlice) Timefree() StationSlice {
+	return s.Filter(func(station Station) bool {
+		return station.Timefree == 1
+	})
+}
+
+// Search returns the stations whose ID, name, ASCII name or reading matches the query, the best match first.
+//
+// The query is compared ignoring case, spaces, the width of characters and the difference between katakana and hiragana. So that "tokyo fm", "ＴＯＫＹＯ ＦＭ" and "トーキョーエフエム" find the TOKYO FM. The stations containing the characters of the query in order are matched as well.
+func (s StationSlice) Search(query string) StationSlice {
+	query = foldStationName(query)
+
+	if query == "" {
+		return nil
+	}
+
+	var matches []stationMatch
+
+	for i := range s {
+		score := -1
+
+		for _, name := range []string{s[i].ID, s[i].Name, s[i].AsciiName, s[i].Ruby} {
+			if v := matchScore(foldStationName(name), query); v >= 0 && (score < 0 || v < score) {
+				score = v
+			}
+		}
+		if score >= 0 {
+			matches = append(matches, stationMatch{station: s[i], score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score < matches[j].score
+	})
+
+	stations := make(StationSlice, len(matches))
+
+	for i := range matches {
+		stations[i] = matches[i].station
+	}
+
+	return stations
+}
+
+// SortByName returns the stations sorted by the reading of the names.
+func (s StationSlice) SortByName() StationSlice {
+	stations := append(StationSlice(nil), s...)
+
+	sort.SliceStable(stations, func(i, j int) bool {
+		return stationSortKey(stations[i]) < stationSortKey(stations[j])
+	})
+
+	return stations
+}
+
+// SortByRegion returns the stations sorted by the region from north to south, and then by the area.
+func (s StationSlice) SortByRegion() StationSlice {
+	stations := append(StationSlice(nil), s...)
+
+	sort.SliceStable(stations, func(i, j int) bool {
+		if a, b := regionOrder(stations[i].RegionID), regionOrder(stations[j].RegionID); a != b {
+			return a < b
+		}
+
+		return areaNumber(stations[i].AreaID) < areaNumber(stations[j].AreaID)
+	})
+
+	return stations
+}
+
+type stationMatch struct {
+	station Station
+	score   int
+}
+
+// matchScore returns 0 for the exact match, 1 for the prefix, 2 for the substring, 3 for the subsequence, or -1 if s doesn't match the query.
+func matchScore(s, query string) int {
+	switch {
+	case s == "":
+		return -1
+	case s == query:
+		return 0
+	case strings.HasPrefix(s, query):
+		return 1
+	case strings.Contains(s, query):
+		return 2
+	}
+
+	rs := []rune(s)
+	i := 0
+
+	for _, r := range query {
+		for i < len(rs) && rs[i] != r {
+			i++
+		}
+		if i == len(rs) {
+			return -1
+		}
+
+		i++
+	}
+
+	return 3
+}
+
+// foldStationName folds the width, the case and katakana into hiragana, and removes spaces and punctuation.
+func foldStationName(s string) string {
+	var b strings.Builder
+
+	for _, r := range width.Fold.String(s) {
+		switch {
+		case r >= 'ァ' && r <= 'ヶ':
+			b.WriteRune(r - 'ァ' + 'ぁ')
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == 'ー':
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	return b.String()
+}
+
+func stationSortKey(station Station) string {
+	if station.Ruby != "" {
+		return foldStationName(station.Ruby)
+	}
+
+	return foldStationName(station.Name)
+}
+
+var regionIDs = []string{
+	"hokkaido-tohoku",
+	"kanto",
+	"hokuriku-koushinetsu",
+	"chubu",
+	"kinki",
+	"chugoku-shikoku",
+	"kyushu",
+	"zenkoku",
+}
+
+func regionOrder(regionID string) int {
+	for i, id := range regionIDs {
+		if id == regionID {
+			return i
+		}
+	}
+
+	return len(regionIDs)
+}
+
+// areaNumber returns 13 for 'JP13', or 0 for an unknown area.
+func areaNumber(areaID string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(areaID, "JP"))
+
+	return n
+}
+
 func ParseFullStationXML(r io.Reader) (StationSlice, error) {
+	regions, err := ParseRegionXML(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var stations StationSlice
+
+	for i := range regions {
+		stations = append(stations, regions[i].Stations...)
+	}
+
+	return stations, nil
+}
+
+// ParseRegionXML parses the full station list with the stations grouped by region.
+func ParseRegionXML(r io.Reader) ([]Region, error) {
 	var v FullStationXML
 
 	if err := xml.NewDecoder(r).Decode(&v); err != nil {
 		return nil, fmt.Errorf("radiko: failed to parse XML: %w", err)
 	}
 
-	var stations StationSlice
+	regions := make([]Region, len(v.Stations))
+
+	for i, stations := range v.Stations {
+		regions[i] = Region{
+			ID:        stations.RegionID,
+			Name:      stations.RegionName,
+			AsciiName: stations.AsciiName,
+			Stations:  make(StationSlice, len(stations.Station)),
+		}
 
-	for i := range v.Stations {
-		for j := range v.Stations[i].Station {
-			stations = append(stations, v.Stations[i].Station[j])
+		for j, station := range stations.Station {
+			station.RegionID = stations.RegionID
+			regions[i].Stations[j] = station
 		}
 	}
 
-	return stations, nil
+	return regions, nil
+}
+
+// ParseAreaStationXML parses the station list of an area.
+func ParseAreaStationXML(r io.Reader) (StationSlice, error) {
+	var v AreaStationXML
+
+	if err := xml.NewDecoder(r).Decode(&v); err != nil {
+		return nil, fmt.Errorf("radiko: failed to parse XML: %w", err)
+	}
+
+	return StationSlice(v.Station), nil
 }
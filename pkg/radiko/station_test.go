@@ -25,3 +25,166 @@ func TestParseFullStationXML(t *testing.T) {
 		require.NotEmpty(t, stations[i].Name)
 	}
 }
+
+func TestParseAreaStationXML(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "AreaStation.xml"))
+
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	stations, err := ParseAreaStationXML(file)
+
+	require.NoError(t, err)
+	require.Len(t, stations, 3)
+	require.Equal(t, "TBS", stations[0].ID)
+	require.Equal(t, 1, stations[1].Timefree)
+	require.Equal(t, 0, stations[2].Areafree)
+}
+
+func TestParseRegionXML(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "FullStation.xml"))
+
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	regions, err := ParseRegionXML(file)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, regions)
+	require.Equal(t, "hokkaido-tohoku", regions[0].ID)
+	require.Equal(t, "北海道・東北", regions[0].Name)
+	require.Equal(t, "HOKKAIDO TOHOKU", regions[0].AsciiName)
+
+	var stations StationSlice
+
+	for _, region := range regions {
+		require.NotEmpty(t, region.Stations)
+
+		for _, station := range region.Stations {
+			require.Equal(t, region.ID, station.RegionID)
+			require.Contains(t, Prefectures, station.AreaID)
+		}
+
+		stations = append(stations, region.Stations...)
+	}
+
+	kanto := stations.InRegion("KANTO")
+
+	require.NotEmpty(t, kanto)
+	require.Equal(t, "kanto", kanto[0].RegionID)
+
+	tokyo := stations.InArea("JP13")
+
+	require.NotEmpty(t, tokyo)
+
+	for _, station := range tokyo {
+		require.Equal(t, "JP13", station.AreaID)
+	}
+}
+
+func TestNormalizeAreaID(t *testing.T) {
+	for _, s := range []string{"13", "jp13", " JP13 "} {
+		require.Equal(t, "JP13", NormalizeAreaID(s))
+	}
+
+	require.Len(t, Prefectures, 47)
+	require.Equal(t, "東京都", Prefectures["JP13"])
+}
+
+func TestStationLogo(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "FullStation.xml"))
+
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	stations, err := ParseFullStationXML(file)
+
+	require.NoError(t, err)
+
+	station := stations[0]
+
+	require.Equal(t, "HBC", station.ID)
+	require.Len(t, station.Logos, 8)
+	require.Equal(t, "https://www.hbc.co.jp", station.Href)
+	require.Equal(t, "http://example.com/res/banner/HBC/20160908091323.png", station.Banner)
+	require.Equal(t, 150, station.TimefreeMaxDelay)
+
+	logo, ok := station.Logo(258, 60)
+
+	require.True(t, ok)
+	require.Equal(t, Logo{Width: 258, Height: 60, URL: "https://example.com/v2/static/station/logo/HBC/258x60.png", Align: "center"}, logo)
+
+	logo, ok = station.Logo(300, 100)
+
+	require.True(t, ok)
+	require.Equal(t, 448, logo.Width)
+
+	logo, ok = station.Logo(1000, 1000)
+
+	require.True(t, ok)
+	require.Equal(t, 688, logo.Width)
+
+	_, ok = Station{}.Logo(224, 100)
+
+	require.False(t, ok)
+}
+
+func TestStationSliceQuery(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "FullStation.xml"))
+
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	stations, err := ParseFullStationXML(file)
+
+	require.NoError(t, err)
+
+	station, ok := stations.Find("fmt")
+
+	require.True(t, ok)
+	require.Equal(t, "TOKYO FM", station.Name)
+
+	_, ok = stations.Find("UNKNOWN")
+
+	require.False(t, ok)
+
+	for _, query := range []string{"FMT", "tokyo fm", "ＴＯＫＹＯ　ＦＭ", "トーキョーエフエム", "とーきょーえふえむ"} {
+		found := stations.Search(query)
+
+		require.NotEmpty(t, found, query)
+		require.Equal(t, "FMT", found[0].ID, query)
+	}
+
+	found := stations.Search("NHKラジオ第1")
+
+	require.True(t, len(found) > 1)
+
+	for _, station := range found {
+		require.Contains(t, station.Name, "NHKラジオ第1")
+	}
+
+	require.Empty(t, stations.Search("存在しない放送局"))
+	require.Empty(t, stations.Search(" "))
+
+	require.Len(t, stations.Filter(func(s Station) bool { return s.AreaID == "JP13" }), len(stations.InArea("JP13")))
+	require.Len(t, stations.Areafree(), len(stations)-len(stations.Filter(func(s Station) bool { return s.Areafree == 0 })))
+	require.Len(t, stations.Timefree(), len(stations))
+
+	sorted := stations.SortByName()
+
+	require.Len(t, sorted, len(stations))
+
+	for i := 1; i < len(sorted); i++ {
+		require.True(t, stationSortKey(sorted[i-1]) <= stationSortKey(sorted[i]))
+	}
+
+	sorted = stations.SortByRegion()
+
+	require.Equal(t, "hokkaido-tohoku", sorted[0].RegionID)
+	require.Equal(t, "JP1", sorted[0].AreaID)
+	require.Equal(t, "zenkoku", sorted[len(sorted)-1].RegionID)
+}
@@ -0,0 +1,183 @@
+package radiko
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxArtworkSize limits the size of the artwork downloaded for tags.
+const maxArtworkSize = 10 << 20
+
+// Tags represents the metadata embedded into a recorded file.
+type Tags struct {
+	Title string
+
+	// Artist is the performers of the program.
+	Artist string
+
+	// Album is the name of the station.
+	Album string
+
+	// Date is the air date.
+	Date time.Time
+
+	// Comment is the description of the program.
+	Comment string
+
+	// Artwork is an image in JPEG or PNG format.
+	Artwork []byte
+}
+
+// ProgramTags returns the tags of the program. The artwork is downloaded from the image of the program, and it is left empty when the download fails.
+func (c *Client) ProgramTags(ctx context.Context, program Program) Tags {
+	tags := Tags{
+		Title:   program.Title,
+		Artist:  strings.Join(program.Performers, ", "),
+		Album:   program.StationName,
+		Date:    program.Start,
+		Comment: program.Description,
+	}
+
+	if tags.Album == "" {
+		tags.Album = program.StationID
+	}
+	if program.Image != "" {
+		artwork, err := c.fetchArtwork(ctx, program.Image)
+
+		if err != nil {
+			c.debug.Printf("tags: failed to fetch artwork: %v\n", err)
+		}
+
+		tags.Artwork = artwork
+	}
+
+	return tags
+}
+
+func (c *Client) fetchArtwork(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("artwork: failed to create request: %w", err)
+	}
+
+	res, err := c.do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("artwork: error response: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("artwork: %w", &HTTPStatusError{Step: "artwork", StatusCode: res.StatusCode, Status: res.Status})
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxArtworkSize))
+
+	if err != nil {
+		return nil, fmt.Errorf("artwork: failed to read response body: %w", err)
+	}
+
+	return data, nil
+}
+
+func (t Tags) date() string {
+	if t.Date.IsZero() {
+		return ""
+	}
+
+	return t.Date.In(JST).Format("2006-01-02T15:04:05")
+}
+
+// artworkType returns the MIME type of the artwork.
+func (t Tags) artworkType() string {
+	return http.DetectContentType(t.Artwork)
+}
+
+// udta returns the 'udta' box which contains the iTunes style metadata.
+func (t Tags) udta() []byte {
+	var items [][]byte
+
+	text := func(typ, value string) {
+		if value != "" {
+			// The well-known type 1 is UTF-8 text.
+			items = append(items, mp4Box(typ, mp4Box("data", u32(1), u32(0), []byte(value))))
+		}
+	}
+
+	text("\xa9nam", t.Title)
+	text("\xa9ART", t.Artist)
+	text("\xa9alb", t.Album)
+	text("\xa9day", t.date())
+	text("\xa9cmt", t.Comment)
+	text("desc", t.Comment)
+
+	if len(t.Artwork) > 0 {
+		// The well-known types 13 and 14 are JPEG and PNG.
+		typ := uint32(13)
+
+		if t.artworkType() == "image/png" {
+			typ = 14
+		}
+
+		items = append(items, mp4Box("covr", mp4Box("data", u32(typ), u32(0), t.Artwork)))
+	}
+
+	hdlr := mp4FullBox("hdlr", 0, 0, u32(0), []byte("mdir"), []byte("appl"), make([]byte, 8), []byte{0})
+
+	return mp4Box("udta", mp4FullBox("meta", 0, 0, hdlr, mp4Box("ilst", items...)))
+}
+
+// ID3 returns the tags encoded as an ID3v2.4 tag, which is placed at the beginning of MP3 and AAC files.
+func (t Tags) ID3() []byte {
+	var frames []byte
+
+	frame := func(id string, payloads ...[]byte) {
+		size := 0
+
+		for _, payload := range payloads {
+			size += len(payload)
+		}
+
+		frames = append(frames, id...)
+		frames = append(frames, syncsafe(size)...)
+		frames = append(frames, 0, 0)
+
+		for _, payload := range payloads {
+			frames = append(frames, payload...)
+		}
+	}
+	text := func(id, value string) {
+		if value != "" {
+			// The encoding 3 is UTF-8.
+			frame(id, []byte{3}, []byte(value))
+		}
+	}
+
+	text("TIT2", t.Title)
+	text("TPE1", t.Artist)
+	text("TALB", t.Album)
+	text("TDRC", t.date())
+
+	if t.Comment != "" {
+		frame("COMM", []byte{3}, []byte("jpn"), []byte{0}, []byte(t.Comment))
+	}
+	if len(t.Artwork) > 0 {
+		// The picture type 3 is the front cover.
+		frame("APIC", []byte{3}, []byte(t.artworkType()), []byte{0, 3, 0}, t.Artwork)
+	}
+
+	header := append([]byte("ID3\x04\x00\x00"), syncsafe(len(frames))...)
+
+	return append(header, frames...)
+}
+
+// syncsafe encodes v as a 28-bit syncsafe integer used in ID3v2.
+func syncsafe(v int) []byte {
+	return []byte{byte(v>>21) & 0x7f, byte(v>>14) & 0x7f, byte(v>>7) & 0x7f, byte(v) & 0x7f}
+}
@@ -0,0 +1,87 @@
+package radiko
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moutend/go-radiko/pkg/radiko/radikotest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagsID3(t *testing.T) {
+	tags := Tags{
+		Title:   "Tokyo Breakfast",
+		Artist:  "Jane Doe",
+		Album:   "TOKYO FM",
+		Date:    time.Date(2023, 10, 17, 6, 0, 0, 0, JST),
+		Comment: "Good morning",
+		Artwork: []byte("\x89PNG\r\n\x1a\n"),
+	}
+
+	data := tags.ID3()
+
+	require.Equal(t, "ID3\x04\x00\x00", string(data[:6]))
+	require.Equal(t, len(data)-10, int(data[6])<<21|int(data[7])<<14|int(data[8])<<7|int(data[9]))
+	require.True(t, bytes.Contains(data, []byte("TIT2\x00\x00\x00\x10\x00\x00\x03Tokyo Breakfast")))
+	require.True(t, bytes.Contains(data, []byte("TDRC\x00\x00\x00\x14\x00\x00\x032023-10-17T06:00:00")))
+	require.True(t, bytes.Contains(data, []byte("APIC")))
+	require.True(t, bytes.Contains(data, []byte("image/png\x00\x03\x00")))
+
+	// The tag is skipped when reading the audio.
+	require.Empty(t, stripID3(data))
+}
+
+func TestRecProgramTags(t *testing.T) {
+	server := radikotest.NewServer()
+
+	defer server.Close()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+
+	server.Programs = []radikotest.Program{
+		{ID: "1", StationID: "FMT", Start: start, End: start.Add(10 * time.Second), Title: "Morning News", Performer: "Jane Doe、John Doe"},
+	}
+
+	client := newTestClient(server, "FMT", "", "")
+
+	require.NoError(t, client.GetWeeklyPrograms(context.Background()))
+
+	program, ok := client.Programs.At(start)
+
+	require.True(t, ok)
+
+	output := filepath.Join(t.TempDir(), "output.m4a")
+
+	require.NoError(t, client.RecProgram(context.Background(), program, output))
+
+	data, err := os.ReadFile(output)
+
+	require.NoError(t, err)
+
+	meta := findBox(data, "moov", "udta", "meta")
+
+	// Skip the version and flags of the full box.
+	ilst := findBox(meta[4:], "ilst")
+
+	require.Equal(t, "Morning News", string(findBox(ilst, "\xa9nam", "data")[8:]))
+	require.Equal(t, "Jane Doe, John Doe", string(findBox(ilst, "\xa9ART", "data")[8:]))
+	require.Equal(t, "FMT", string(findBox(ilst, "\xa9alb", "data")[8:]))
+	require.Equal(t, start.In(JST).Format("2006-01-02T15:04:05"), string(findBox(ilst, "\xa9day", "data")[8:]))
+
+	covr := findBox(ilst, "covr", "data")
+
+	require.Equal(t, []byte{0, 0, 0, 14}, covr[:4])
+	require.Equal(t, 1, server.Requests(radikotest.EndpointImage))
+
+	// The tags are not embedded with WithoutTags.
+	require.NoError(t, client.RecProgram(context.Background(), program, output, WithoutTags()))
+
+	data, err = os.ReadFile(output)
+
+	require.NoError(t, err)
+	require.Nil(t, findBox(data, "moov", "udta"))
+}